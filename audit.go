@@ -0,0 +1,98 @@
+package feature
+
+import (
+	"sync"
+	"time"
+)
+
+// AuditEntry contains information about a single read of a flag.
+type AuditEntry struct {
+	// Time is the time at which the flag was read.
+	Time time.Time
+
+	// Value is the value returned by the flag.
+	Value any
+}
+
+type auditLog struct {
+	size int
+
+	mu    sync.Mutex
+	flags map[string]*auditRing
+}
+
+type auditRing struct {
+	entries []AuditEntry
+	next    int
+}
+
+func (a *auditLog) record(name string, value any) {
+	e := AuditEntry{Time: time.Now(), Value: value}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r := a.flags[name]
+	if r == nil {
+		r = &auditRing{entries: make([]AuditEntry, 0, a.size)}
+		a.flags[name] = r
+	}
+
+	if len(r.entries) < a.size {
+		r.entries = append(r.entries, e)
+		return
+	}
+
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % a.size
+}
+
+func (a *auditLog) entries(name string) []AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r := a.flags[name]
+	if r == nil {
+		return nil
+	}
+
+	entries := make([]AuditEntry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	entries = append(entries, r.entries[:r.next]...)
+	return entries
+}
+
+// EnableAudit enables recording of the last size reads for each flag, which can be retrieved using
+// [FlagSet.AuditLog].
+//
+// Calling EnableAudit again discards all recorded entries. If size is zero or negative, auditing is disabled.
+func (s *FlagSet) EnableAudit(size int) {
+	defer s.updateHooked()
+
+	if size <= 0 {
+		s.audit.Store(nil)
+		return
+	}
+
+	s.audit.Store(&auditLog{size: size, flags: make(map[string]*auditRing)})
+}
+
+// AuditLog returns the recorded reads for the flag with the given name, oldest first.
+//
+// If auditing is not enabled via [FlagSet.EnableAudit], AuditLog returns nil.
+func (s *FlagSet) AuditLog(name string) []AuditEntry {
+	a := s.audit.Load()
+	if a == nil {
+		return nil
+	}
+
+	name = s.normalize(name)
+
+	s.flagsMu.Lock()
+	if target, ok := s.aliases[name]; ok {
+		name = target
+	}
+	s.flagsMu.Unlock()
+
+	return a.entries(name)
+}
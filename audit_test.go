@@ -0,0 +1,68 @@
+package feature_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nussjustin/feature"
+)
+
+func TestFlagSet_AuditLog(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+
+	var value int64
+
+	set.SetRegistry(&feature.SimpleRegistry{IntFunc: func(context.Context, string) int64 {
+		value++
+		return value
+	}})
+
+	f := set.Int("counter")
+	set.Int("unread")
+
+	_ = f(ctx)
+
+	if got := set.AuditLog("counter"); got != nil {
+		t.Errorf("expected no entries before enabling audit, got %v", got)
+	}
+
+	set.EnableAudit(3)
+
+	for range 5 {
+		_ = f(ctx)
+	}
+
+	entries := set.AuditLog("counter")
+
+	values := make([]any, len(entries))
+	for i, e := range entries {
+		values[i] = e.Value
+
+		if e.Time.IsZero() {
+			t.Errorf("entry %d has no time", i)
+		}
+
+		if i > 0 && e.Time.Before(entries[i-1].Time) {
+			t.Errorf("entry %d is older than previous entry", i)
+		}
+	}
+
+	assertEquals(t, []any{int64(4), int64(5), int64(6)}, values, "")
+	assertEquals(t, 0, len(set.AuditLog("unread")), "")
+
+	if err := set.Alias("old-counter", "counter"); err != nil {
+		t.Fatalf("failed to create alias: %s", err)
+	}
+
+	assertEquals(t, entries, set.AuditLog("old-counter"), "entries not resolved via alias")
+
+	set.EnableAudit(0)
+
+	_ = f(ctx)
+
+	if got := set.AuditLog("counter"); got != nil {
+		t.Errorf("expected no entries after disabling audit, got %v", got)
+	}
+}
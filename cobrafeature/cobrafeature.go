@@ -0,0 +1,83 @@
+// Package cobrafeature implements commands for inspecting a [feature.FlagSet] using [cobra].
+package cobrafeature
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nussjustin/feature"
+)
+
+// Command returns a new command named "feature" for inspecting the flags in the given [feature.FlagSet].
+//
+// The command has the following subcommands:
+//
+//   - list: Lists all flags with their kind and description.
+//   - get NAME: Prints the details of a single flag.
+func Command(s *feature.FlagSet) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "feature",
+		Short: "Inspect feature flags",
+	}
+
+	cmd.AddCommand(listCommand(s), getCommand(s))
+
+	return cmd
+}
+
+func listCommand(s *feature.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List all feature flags",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+			_, _ = fmt.Fprintln(w, "NAME\tKIND\tDESCRIPTION")
+
+			s.All(func(f feature.Flag) bool {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, f.Kind, f.Description)
+				return true
+			})
+
+			return w.Flush()
+		},
+	}
+}
+
+func getCommand(s *feature.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get NAME",
+		Short: "Print details of a single feature flag",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, ok := s.Lookup(args[0])
+			if !ok {
+				return fmt.Errorf("%w: %s", feature.ErrUnknownFlag, args[0])
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+			_, _ = fmt.Fprintf(w, "Name:\t%s\n", f.Name)
+			_, _ = fmt.Fprintf(w, "Kind:\t%s\n", f.Kind)
+			_, _ = fmt.Fprintf(w, "Description:\t%s\n", f.Description)
+
+			if f.DefinedAt != "" {
+				_, _ = fmt.Fprintf(w, "Defined at:\t%s\n", f.DefinedAt)
+			}
+
+			if f.Labels.Len() > 0 {
+				_, _ = fmt.Fprintln(w, "Labels:")
+
+				f.Labels.All(func(key, value string) bool {
+					_, _ = fmt.Fprintf(w, "  %s:\t%s\n", key, value)
+					return true
+				})
+			}
+
+			return w.Flush()
+		},
+	}
+}
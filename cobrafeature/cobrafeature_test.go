@@ -0,0 +1,72 @@
+package cobrafeature_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/nussjustin/feature"
+	"github.com/nussjustin/feature/cobrafeature"
+)
+
+func TestCommand(t *testing.T) {
+	var set feature.FlagSet
+
+	set.Bool("new-ui", feature.WithDescription("enables the new UI"), feature.WithLabel("team", "frontend"))
+	set.Int("limit", feature.WithDescription("request limit"))
+
+	run := func(args ...string) (string, error) {
+		var buf bytes.Buffer
+
+		cmd := cobrafeature.Command(&set)
+		cmd.SetArgs(args)
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	t.Run("List", func(t *testing.T) {
+		got, err := run("list")
+		if err != nil {
+			t.Fatalf("failed to run command: %s", err)
+		}
+
+		want := "" +
+			"NAME    KIND  DESCRIPTION\n" +
+			"limit   int   request limit\n" +
+			"new-ui  bool  enables the new UI\n"
+
+		if got != want {
+			t.Errorf("output mismatch\nwant:\n%s\ngot:\n%s", want, got)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		got, err := run("get", "new-ui")
+		if err != nil {
+			t.Fatalf("failed to run command: %s", err)
+		}
+
+		want := "" +
+			"Name:         new-ui\n" +
+			"Kind:         bool\n" +
+			"Description:  enables the new UI\n" +
+			"Labels:\n" +
+			"  team:  frontend\n"
+
+		if got != want {
+			t.Errorf("output mismatch\nwant:\n%s\ngot:\n%s", want, got)
+		}
+	})
+
+	t.Run("Get unknown", func(t *testing.T) {
+		_, err := run("get", "unknown")
+		if !errors.Is(err, feature.ErrUnknownFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrUnknownFlag, err)
+		}
+	})
+}
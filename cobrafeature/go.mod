@@ -0,0 +1,13 @@
+module github.com/nussjustin/feature/cobrafeature
+
+go 1.22
+
+require (
+	github.com/nussjustin/feature v0.0.0-20261017001024-da84196a6d58
+	github.com/spf13/cobra v1.8.1
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+)
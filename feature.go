@@ -2,18 +2,100 @@ package feature
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"path"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"text/template"
+	"time"
 )
 
+// ErrDependencyCycle is returned when registering a flag or alias would make the dependencies specified via
+// [WithRequires] form a cycle.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
 // ErrDuplicateFlag is returned by if a flag with a given name is already registered.
 var ErrDuplicateFlag = errors.New("duplicate flag")
 
+// ErrInvalidDependency is returned when registering a flag or alias would make a flag depend on a flag that is not a
+// boolean flag.
+var ErrInvalidDependency = errors.New("invalid dependency")
+
+// ErrInvalidKind is returned when registering a flag from a [Spec] with a [FlagKind] that is not valid.
+var ErrInvalidKind = errors.New("invalid kind")
+
+// ErrFrozen is returned when trying to register a flag on a [FlagSet] after calling [FlagSet.Freeze].
+var ErrFrozen = errors.New("flag set is frozen")
+
+// ErrUnknownFlag is returned when referencing a flag that is not registered.
+var ErrUnknownFlag = errors.New("unknown flag")
+
+// FlagKind is an enum of the different types of values a flag can have.
+type FlagKind uint8
+
+const (
+	// FlagKindInvalid is the zero value of FlagKind and does not represent a valid kind.
+	FlagKindInvalid FlagKind = iota
+
+	// FlagKindBool is the kind of flags registered using [FlagSet.Bool].
+	FlagKindBool
+
+	// FlagKindFloat is the kind of flags registered using [FlagSet.Float].
+	FlagKindFloat
+
+	// FlagKindInt is the kind of flags registered using [FlagSet.Int].
+	FlagKindInt
+
+	// FlagKindString is the kind of flags registered using [FlagSet.String].
+	FlagKindString
+
+	// FlagKindUint is the kind of flags registered using [FlagSet.Uint].
+	FlagKindUint
+)
+
+var allKinds = []FlagKind{FlagKindBool, FlagKindFloat, FlagKindInt, FlagKindString, FlagKindUint}
+
+// Kinds returns all valid flag kinds.
+func Kinds() []FlagKind {
+	return slices.Clone(allKinds)
+}
+
+// String returns the name of the kind, for example "bool" for [FlagKindBool].
+func (k FlagKind) String() string {
+	switch k {
+	case FlagKindBool:
+		return "bool"
+	case FlagKindFloat:
+		return "float"
+	case FlagKindInt:
+		return "int"
+	case FlagKindString:
+		return "string"
+	case FlagKindUint:
+		return "uint"
+	case FlagKindInvalid:
+		fallthrough
+	default:
+		return "FlagKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
 // Flag represents a flag registered with a [FlagSet].
 type Flag struct {
-	// Name is the name of the feature as passed to [Register].
+	// Kind is the kind of value of the flag.
+	Kind FlagKind
+
+	// Name is the name of the feature as passed to the registration method, for example [FlagSet.Bool].
 	Name string
 
 	// Description is an optional description specified using [WithDescription].
@@ -22,18 +104,128 @@ type Flag struct {
 	// Labels contains the labels specified via [WithLabels].
 	Labels Labels
 
+	// Requires is the name of the boolean flag this flag depends on, as specified via [WithRequires].
+	Requires string
+
+	// DefinedAt contains the file and line at which the flag was registered in the form "file:line".
+	//
+	// This is only set if caller capturing was enabled using [FlagSet.SetCaptureCallers].
+	DefinedAt string
+
 	// Func is callback that returns the value for the flag and is either a [BoolFunc], [IntFunc] or [StringFunc].
 	Func any
 }
 
+// Bool returns the value of the flag and true if the flag is a boolean flag.
+//
+// If the flag is not a boolean flag, Bool returns false, false.
+func (f Flag) Bool(ctx context.Context) (bool, bool) {
+	fn, ok := f.Func.(func(context.Context) bool)
+	if !ok {
+		return false, false
+	}
+	return fn(ctx), true
+}
+
+// Float returns the value of the flag and true if the flag is a float flag.
+//
+// If the flag is not a float flag, Float returns 0.0, false.
+func (f Flag) Float(ctx context.Context) (float64, bool) {
+	fn, ok := f.Func.(func(context.Context) float64)
+	if !ok {
+		return 0.0, false
+	}
+	return fn(ctx), true
+}
+
+// Int returns the value of the flag and true if the flag is an int flag.
+//
+// If the flag is not an int flag, Int returns 0, false.
+func (f Flag) Int(ctx context.Context) (int64, bool) {
+	fn, ok := f.Func.(func(context.Context) int64)
+	if !ok {
+		return 0, false
+	}
+	return fn(ctx), true
+}
+
+// String returns the value of the flag and true if the flag is a string flag.
+//
+// If the flag is not a string flag, String returns "", false.
+func (f Flag) String(ctx context.Context) (string, bool) {
+	fn, ok := f.Func.(func(context.Context) string)
+	if !ok {
+		return "", false
+	}
+	return fn(ctx), true
+}
+
+// Uint returns the value of the flag and true if the flag is an uint flag.
+//
+// If the flag is not an uint flag, Uint returns 0, false.
+func (f Flag) Uint(ctx context.Context) (uint64, bool) {
+	fn, ok := f.Func.(func(context.Context) uint64)
+	if !ok {
+		return 0, false
+	}
+	return fn(ctx), true
+}
+
+func (f Flag) value(ctx context.Context) any {
+	return evaluate(ctx, f.Func)
+}
+
+// evaluate calls fn, which must be one of the functions stored in [Flag.Func], and returns the result.
+func evaluate(ctx context.Context, fn any) any {
+	switch fn := fn.(type) {
+	case func(context.Context) bool:
+		return fn(ctx)
+	case func(context.Context) float64:
+		return fn(ctx)
+	case func(context.Context) int64:
+		return fn(ctx)
+	case func(context.Context) string:
+		return fn(ctx)
+	case func(context.Context) uint64:
+		return fn(ctx)
+	default:
+		return nil
+	}
+}
+
+// FlagValue contains the name of a flag and a value returned by the flag.
+type FlagValue struct {
+	// Name is the name of the flag.
+	Name string
+
+	// Value is the value returned by the flag.
+	Value any
+}
+
 // FlagSet represents a set of defined feature flags.
 //
 // The zero value is valid and returns zero values for all flags.
 type FlagSet struct {
-	registry atomic.Pointer[Registry]
+	registry       atomic.Pointer[Registry]
+	normalizer     atomic.Pointer[func(string) string]
+	captureCallers atomic.Bool
+
+	latencyObserver atomic.Pointer[func(name string, d time.Duration)]
+	panicHandler    atomic.Pointer[func(name string, recovered any)]
+
+	audit      atomic.Pointer[auditLog]
+	lastValues atomic.Pointer[lastValues]
+
+	// hooked is true if any of the optional features that need to observe flag reads is enabled.
+	hooked atomic.Bool
 
 	flagsMu sync.Mutex
 	flags   sortedMap[Flag]
+	aliases map[string]string
+	frozen  bool
+
+	lazyMu sync.Mutex
+	lazy   []lazyProvider
 }
 
 // Labels is a read only map collection of labels associated with a feature flag.
@@ -56,27 +248,741 @@ func (l *Labels) Len() int {
 }
 
 // All yields all registered flags sorted by name.
+//
+// If the set was made unordered using [FlagSet.SetUnordered], flags are yielded in the order they were registered.
 func (s *FlagSet) All(yield func(Flag) bool) {
+	s.all(false, yield)
+}
+
+// sorted yields all registered flags sorted by name, even if the set is unordered.
+func (s *FlagSet) sorted(yield func(Flag) bool) {
+	s.all(true, yield)
+}
+
+func (s *FlagSet) all(sorted bool, yield func(Flag) bool) {
+	s.loadLazy()
+
 	s.flagsMu.Lock()
 	flags := s.flags
 	s.flagsMu.Unlock()
 
-	for _, key := range flags.keys {
+	keys := flags.keys
+	if sorted && flags.unordered {
+		keys = slices.Clone(keys)
+		slices.Sort(keys)
+	}
+
+	for _, key := range keys {
 		if !yield(flags.m[key]) {
 			return
 		}
 	}
 }
 
+// Alias registers oldName as an alternative name for the flag newName.
+//
+// After this call, methods like [FlagSet.Lookup] and [FlagSet.SetDescription] will resolve oldName to the flag with
+// the name newName. Aliases are not returned by [FlagSet.All].
+//
+// If a flag or alias with the name oldName already exists, an error that is [ErrDuplicateFlag] is returned. If no flag
+// with the name newName exists, an error that is [ErrUnknownFlag] is returned. If the alias would make the
+// dependencies of newName form a cycle, an error that is [ErrDependencyCycle] is returned. If a flag requires oldName
+// and newName is not a boolean flag, an error that is [ErrInvalidDependency] is returned.
+func (s *FlagSet) Alias(oldName, newName string) error {
+	s.loadLazy()
+
+	oldName, newName = s.normalize(oldName), s.normalize(newName)
+
+	s.flagsMu.Lock()
+	defer s.flagsMu.Unlock()
+
+	if _, ok := s.flags.m[oldName]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateFlag, oldName)
+	}
+
+	if _, ok := s.aliases[oldName]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateFlag, oldName)
+	}
+
+	f, ok := s.flags.m[newName]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, newName)
+	}
+
+	if f.Kind != FlagKindBool {
+		if child, ok := requiredBy(s.flags.m, oldName); ok {
+			return fmt.Errorf("%w: %s requires %s flag %s", ErrInvalidDependency, child.Name, f.Kind, oldName)
+		}
+	}
+
+	if s.aliases == nil {
+		s.aliases = make(map[string]string)
+	}
+
+	s.aliases[oldName] = newName
+
+	if s.cycleLocked(s.flags.m, newName, f.Requires) {
+		delete(s.aliases, oldName)
+		return fmt.Errorf("%w: %s", ErrDependencyCycle, oldName)
+	}
+
+	return nil
+}
+
+// BoolOr returns the value of the boolean flag with the given name.
+//
+// If no flag with the given name is registered or the flag is not a boolean flag, fallback is returned.
+func (s *FlagSet) BoolOr(ctx context.Context, name string, fallback bool) bool {
+	f, ok := s.Lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	v, ok := f.Bool(ctx)
+	if !ok {
+		return fallback
+	}
+	return v
+}
+
+// FloatOr returns the value of the float flag with the given name.
+//
+// If no flag with the given name is registered or the flag is not a float flag, fallback is returned.
+func (s *FlagSet) FloatOr(ctx context.Context, name string, fallback float64) float64 {
+	f, ok := s.Lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	v, ok := f.Float(ctx)
+	if !ok {
+		return fallback
+	}
+	return v
+}
+
+// IntOr returns the value of the int flag with the given name.
+//
+// If no flag with the given name is registered or the flag is not an int flag, fallback is returned.
+func (s *FlagSet) IntOr(ctx context.Context, name string, fallback int64) int64 {
+	f, ok := s.Lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	v, ok := f.Int(ctx)
+	if !ok {
+		return fallback
+	}
+	return v
+}
+
+// StringOr returns the value of the string flag with the given name.
+//
+// If no flag with the given name is registered or the flag is not a string flag, fallback is returned.
+func (s *FlagSet) StringOr(ctx context.Context, name string, fallback string) string {
+	f, ok := s.Lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	v, ok := f.String(ctx)
+	if !ok {
+		return fallback
+	}
+	return v
+}
+
+// UintOr returns the value of the uint flag with the given name.
+//
+// If no flag with the given name is registered or the flag is not an uint flag, fallback is returned.
+func (s *FlagSet) UintOr(ctx context.Context, name string, fallback uint64) uint64 {
+	f, ok := s.Lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	v, ok := f.Uint(ctx)
+	if !ok {
+		return fallback
+	}
+	return v
+}
+
+// BoolSnapshot evaluates all boolean flags and returns their names and values as parallel slices, sorted by name.
+func (s *FlagSet) BoolSnapshot(ctx context.Context) (names []string, bits []bool) {
+	s.sorted(func(f Flag) bool {
+		if v, ok := f.Bool(ctx); ok {
+			names = append(names, f.Name)
+			bits = append(bits, v)
+		}
+		return true
+	})
+
+	return names, bits
+}
+
+// EnvConflicts returns the names of flags that map to the same environment variable name.
+//
+// The environment variable name for a flag is the given prefix followed by the flag name converted to upper case, with
+// every character that is not an ASCII letter or digit replaced by an underscore.
+//
+// The returned map is keyed by environment variable name and only contains names to which more than one flag maps.
+// Flag names are sorted.
+func (s *FlagSet) EnvConflicts(prefix string) map[string][]string {
+	byEnv := make(map[string][]string)
+
+	s.sorted(func(f Flag) bool {
+		env := prefix + strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				return r
+			default:
+				return '_'
+			}
+		}, f.Name)
+
+		byEnv[env] = append(byEnv[env], f.Name)
+		return true
+	})
+
+	conflicts := make(map[string][]string)
+
+	for env, names := range byEnv {
+		if len(names) > 1 {
+			conflicts[env] = names
+		}
+	}
+
+	return conflicts
+}
+
+// EvaluateAll evaluates the flags with the given names using the given context and returns their values by name.
+//
+// All names are resolved at once, avoiding the per call overhead of using [FlagSet.Lookup] for each name.
+//
+// If any of the flags is not registered, an error is returned that is [ErrUnknownFlag] and lists all missing names.
+func (s *FlagSet) EvaluateAll(ctx context.Context, names ...string) (map[string]any, error) {
+	s.loadLazy()
+
+	// Only collect the functions, as copying whole flags while holding the lock is comparatively expensive
+	funcs := make([]any, len(names))
+
+	var errs []error
+
+	s.flagsMu.Lock()
+	for i, name := range names {
+		name = s.normalize(name)
+
+		if target, ok := s.aliases[name]; ok {
+			name = target
+		}
+
+		f, ok := s.flags.m[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownFlag, names[i]))
+			continue
+		}
+
+		funcs[i] = f.Func
+	}
+	s.flagsMu.Unlock()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	values := make(map[string]any, len(names))
+
+	for i, fn := range funcs {
+		values[names[i]] = evaluate(ctx, fn)
+	}
+
+	return values, nil
+}
+
+// Export returns a [Spec] for each registered flag, sorted by name.
+//
+// The returned specs can be passed to [LoadSpecs] to create a new [FlagSet] with the same flags.
+func (s *FlagSet) Export() []Spec {
+	var specs []Spec
+
+	s.sorted(func(f Flag) bool {
+		var labels map[string]string
+		if f.Labels.Len() > 0 {
+			labels = make(map[string]string, f.Labels.Len())
+			f.Labels.All(func(key, value string) bool {
+				labels[key] = value
+				return true
+			})
+		}
+
+		specs = append(specs, Spec{
+			Kind:        f.Kind,
+			Name:        f.Name,
+			Description: f.Description,
+			Labels:      labels,
+			Requires:    f.Requires,
+		})
+		return true
+	})
+
+	return specs
+}
+
+// Groups returns all registered flags grouped by the part of their name before the last occurrence of sep.
+//
+// Flags whose names do not contain sep are grouped under the empty string. If sep is empty, all flags are grouped
+// under the empty string.
+//
+// Flags in each group are sorted by name.
+func (s *FlagSet) Groups(sep string) map[string][]Flag {
+	m := make(map[string][]Flag)
+
+	s.sorted(func(f Flag) bool {
+		var group string
+		if i := strings.LastIndex(f.Name, sep); sep != "" && i >= 0 {
+			group = f.Name[:i]
+		}
+		m[group] = append(m[group], f)
+		return true
+	})
+
+	return m
+}
+
+type lastValues struct {
+	values sync.Map
+}
+
+func recordLastValue[T comparable](l *lastValues, name string, value T) {
+	// Only store changed values to avoid allocating on every read
+	if old, ok := l.values.Load(name); ok && old == any(value) {
+		return
+	}
+
+	l.values.Store(name, value)
+}
+
+// EnableLastValues enables or disables tracking of the value last returned by each flag, which can be retrieved
+// using [FlagSet.LastValues].
+//
+// Calling EnableLastValues again discards all tracked values.
+func (s *FlagSet) EnableLastValues(enabled bool) {
+	defer s.updateHooked()
+
+	if !enabled {
+		s.lastValues.Store(nil)
+		return
+	}
+
+	s.lastValues.Store(&lastValues{})
+}
+
+// LastValues returns the value last returned by each flag, keyed by flag name.
+//
+// Flags that have not been read yet are not included. If tracking is not enabled via [FlagSet.EnableLastValues],
+// LastValues returns an empty map.
+func (s *FlagSet) LastValues() map[string]any {
+	m := make(map[string]any)
+
+	if l := s.lastValues.Load(); l != nil {
+		l.values.Range(func(key, value any) bool {
+			m[key.(string)] = value
+			return true
+		})
+	}
+
+	return m
+}
+
+// Freeze prevents any further flags from being registered.
+//
+// After Freeze was called, calls to registration methods like [FlagSet.Bool] will panic with an error that is
+// [ErrFrozen]. Existing flags are not affected.
+//
+// Flags provided via [FlagSet.RegisterLazy] are registered before the set is frozen.
+//
+// Calling Freeze multiple times has no effect.
+func (s *FlagSet) Freeze() {
+	s.lazyMu.Lock()
+	defer s.lazyMu.Unlock()
+
+	s.loadLazyLocked()
+
+	s.flagsMu.Lock()
+	defer s.flagsMu.Unlock()
+
+	s.frozen = true
+}
+
+// JSONSchema returns a JSON Schema describing an object with a property for each registered flag.
+//
+// The type of each property is derived from the kind of the flag and the description of the flag is used as
+// description for the property.
+func (s *FlagSet) JSONSchema() ([]byte, error) {
+	properties := make(map[string]any)
+
+	s.All(func(f Flag) bool {
+		property := make(map[string]any)
+
+		switch f.Kind {
+		case FlagKindBool:
+			property["type"] = "boolean"
+		case FlagKindFloat:
+			property["type"] = "number"
+		case FlagKindInt:
+			property["type"] = "integer"
+		case FlagKindString:
+			property["type"] = "string"
+		case FlagKindUint:
+			property["type"] = "integer"
+			property["minimum"] = 0
+		case FlagKindInvalid:
+		}
+
+		if f.Description != "" {
+			property["description"] = f.Description
+		}
+
+		properties[f.Name] = property
+		return true
+	})
+
+	return json.Marshal(map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	})
+}
+
 // Lookup returns the flag with the given name.
 func (s *FlagSet) Lookup(name string) (Flag, bool) {
+	s.loadLazy()
+
+	name = s.normalize(name)
+
 	s.flagsMu.Lock()
 	defer s.flagsMu.Unlock()
 
+	return s.lookupLocked(name)
+}
+
+func (s *FlagSet) lookupLocked(name string) (Flag, bool) {
+	if target, ok := s.aliases[name]; ok {
+		name = target
+	}
+
 	f, ok := s.flags.m[name]
 	return f, ok
 }
 
+// Match returns an iterator over all flags whose name matches the given pattern, sorted by name.
+//
+// The pattern uses the syntax of [path.Match]. If the pattern is malformed, no flags are yielded.
+func (s *FlagSet) Match(pattern string) func(yield func(Flag) bool) {
+	return func(yield func(Flag) bool) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return
+		}
+
+		s.sorted(func(f Flag) bool {
+			if ok, _ := path.Match(pattern, f.Name); !ok {
+				return true
+			}
+			return yield(f)
+		})
+	}
+}
+
+// TemplateFuncs returns a [template.FuncMap] with functions for accessing flags in templates.
+//
+// The returned map contains a single function named "feature", which takes the name of a flag and returns the value of
+// the flag for the given context. If no flag with the given name exists, an error that is [ErrUnknownFlag] is
+// returned.
+func (s *FlagSet) TemplateFuncs(ctx context.Context) template.FuncMap {
+	return template.FuncMap{
+		"feature": func(name string) (any, error) {
+			f, ok := s.Lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+			}
+			return f.value(ctx), nil
+		},
+	}
+}
+
+// Require checks that flags with the given names are registered.
+//
+// If any of the flags is not registered, an error is returned that is [ErrUnknownFlag] and lists all missing names.
+func (s *FlagSet) Require(names ...string) error {
+	var errs []error
+
+	for _, name := range names {
+		if _, ok := s.Lookup(name); !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownFlag, name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SchemaEqual compares the flags in s with the flags in other and reports whether they are the same.
+//
+// Flags are compared by name, kind and description. The returned slice contains a human-readable description of each
+// difference, sorted by flag name, where flags only in other are reported as added and flags only in s as removed.
+func (s *FlagSet) SchemaEqual(other *FlagSet) (bool, []string) {
+	var names []string
+
+	flags := make(map[string]Flag)
+	s.All(func(f Flag) bool {
+		names = append(names, f.Name)
+		flags[f.Name] = f
+		return true
+	})
+
+	otherFlags := make(map[string]Flag)
+	other.All(func(f Flag) bool {
+		if _, ok := flags[f.Name]; !ok {
+			names = append(names, f.Name)
+		}
+		otherFlags[f.Name] = f
+		return true
+	})
+
+	slices.Sort(names)
+
+	var diffs []string
+
+	for _, name := range names {
+		f, ok := flags[name]
+		otherF, otherOK := otherFlags[name]
+
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("flag %q added", name))
+		case !otherOK:
+			diffs = append(diffs, fmt.Sprintf("flag %q removed", name))
+		default:
+			if f.Kind != otherF.Kind {
+				diffs = append(diffs, fmt.Sprintf("flag %q kind changed from %s to %s", name, f.Kind, otherF.Kind))
+			}
+
+			if f.Description != otherF.Description {
+				diffs = append(diffs, fmt.Sprintf("flag %q description changed from %q to %q",
+					name, f.Description, otherF.Description))
+			}
+		}
+	}
+
+	return len(diffs) == 0, diffs
+}
+
+// SchemaHash returns a hex encoded SHA-256 hash over the name, kind and description of all registered flags.
+//
+// Sets with the same flags return the same hash, independent of the order in which the flags were registered.
+func (s *FlagSet) SchemaHash() string {
+	h := sha256.New()
+
+	s.sorted(func(f Flag) bool {
+		_, _ = fmt.Fprintf(h, "%q %s %q\n", f.Name, f.Kind, f.Description)
+		return true
+	})
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SnapshotOrdered evaluates all flags using the given context and returns their values sorted by flag name.
+func (s *FlagSet) SnapshotOrdered(ctx context.Context) []FlagValue {
+	var values []FlagValue
+
+	s.sorted(func(f Flag) bool {
+		values = append(values, FlagValue{Name: f.Name, Value: f.value(ctx)})
+		return true
+	})
+
+	return values
+}
+
+// RegisterLazy registers a function that provides specs for flags that should be registered on first use.
+//
+// The provider is called once, the first time flags are accessed using methods like [FlagSet.All] or
+// [FlagSet.Lookup]. For each returned [Spec] a flag is registered.
+//
+// If any of the flags can not be registered, for example because a flag with the same name already exists, none of
+// the provided flags are registered and the method that caused the provider to be called will panic. The provider is
+// discarded in this case. If the provider itself panics, it is kept and called again on the next access.
+//
+// If the set is frozen, RegisterLazy panics with an error that is [ErrFrozen]. Providers registered before calling
+// [FlagSet.Freeze] are called by Freeze.
+//
+// If caller capturing is enabled using [FlagSet.SetCaptureCallers] when the flags are registered, their
+// [Flag.DefinedAt] field contains the location of the call to RegisterLazy.
+//
+// The provider must not call any methods on s.
+func (s *FlagSet) RegisterLazy(provider func() []Spec) {
+	s.lazyMu.Lock()
+	defer s.lazyMu.Unlock()
+
+	s.flagsMu.Lock()
+	frozen := s.frozen
+	s.flagsMu.Unlock()
+
+	if frozen {
+		panic(fmt.Errorf("%w: lazy provider", ErrFrozen))
+	}
+
+	s.lazy = append(s.lazy, lazyProvider{provider: provider, definedAt: definedAt()})
+}
+
+type lazyProvider struct {
+	provider func() []Spec
+
+	// definedAt is the location of the call to [FlagSet.RegisterLazy], used for [Flag.DefinedAt].
+	definedAt string
+}
+
+func (s *FlagSet) loadLazy() {
+	s.lazyMu.Lock()
+	defer s.lazyMu.Unlock()
+
+	s.loadLazyLocked()
+}
+
+func (s *FlagSet) loadLazyLocked() {
+	for len(s.lazy) > 0 {
+		p := s.lazy[0]
+
+		specs := p.provider()
+
+		// Discard the provider before registering, so that a provider with invalid specs is only reported once
+		s.lazy = s.lazy[1:]
+
+		s.flagsMu.Lock()
+		err := s.checkSpecsLocked(specs)
+		s.flagsMu.Unlock()
+
+		if err != nil {
+			panic(err)
+		}
+
+		var opts []Option
+		if s.captureCallers.Load() {
+			opts = append(opts, withDefinedAt(p.definedAt))
+		}
+
+		for _, spec := range specs {
+			if err := s.registerSpec(spec, opts...); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// SetCaptureCallers enables or disables capturing of the location at which flags are registered.
+//
+// If enabled, newly registered flags will have their [Flag.DefinedAt] field set.
+func (s *FlagSet) SetCaptureCallers(capture bool) {
+	s.captureCallers.Store(capture)
+}
+
+// SetDescription updates the description of the flag with the given name.
+//
+// If no flag with the given name is registered, an error that is [ErrUnknownFlag] is returned.
+func (s *FlagSet) SetDescription(name, desc string) error {
+	s.loadLazy()
+
+	name = s.normalize(name)
+
+	s.flagsMu.Lock()
+	defer s.flagsMu.Unlock()
+
+	f, ok := s.lookupLocked(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+
+	f.Description = desc
+
+	s.flags = s.flags.add(f.Name, f)
+
+	return nil
+}
+
+// SetLatencyObserver sets a function that is called with the time it took to get the value for a flag.
+//
+// The function is called after each call to the [Registry]. Reads that do not call the [Registry] because no [Registry]
+// is set are not observed.
+//
+// A nil value removes the observer.
+func (s *FlagSet) SetLatencyObserver(fn func(name string, d time.Duration)) {
+	defer s.updateHooked()
+
+	if fn == nil {
+		s.latencyObserver.Store(nil)
+	} else {
+		s.latencyObserver.Store(&fn)
+	}
+}
+
+// SetNameNormalizer sets a function used to normalize flag names.
+//
+// The function is applied to the names passed to the registration methods like [FlagSet.Bool] as well as to names
+// passed to methods like [FlagSet.Lookup], so that flags can be looked up using any name that normalizes to the name
+// of the flag.
+//
+// SetNameNormalizer must be called before any flags are registered and can only be called once. Otherwise the call
+// will panic.
+func (s *FlagSet) SetNameNormalizer(fn func(string) string) {
+	s.flagsMu.Lock()
+	defer s.flagsMu.Unlock()
+
+	if len(s.flags.keys) > 0 {
+		panic(errors.New("name normalizer must be set before registering flags"))
+	}
+
+	if !s.normalizer.CompareAndSwap(nil, &fn) {
+		panic(errors.New("name normalizer already set"))
+	}
+}
+
+// SetUnordered disables sorting of flags by name, making registration of many flags cheaper.
+//
+// If enabled, [FlagSet.All] will return flags in the order in which they were registered instead of sorted by name.
+// Methods that document sorted output, like [FlagSet.Export] or [FlagSet.SnapshotOrdered], sort their results
+// themselves.
+//
+// SetUnordered must be called before any flags are registered. Otherwise the call will panic.
+func (s *FlagSet) SetUnordered(unordered bool) {
+	s.flagsMu.Lock()
+	defer s.flagsMu.Unlock()
+
+	if len(s.flags.keys) > 0 {
+		panic(errors.New("unordered mode must be set before registering flags"))
+	}
+
+	s.flags.unordered = unordered
+}
+
+// SetPanicHandler sets a function that is called when the [Registry] panics while getting the value for a flag.
+//
+// The function is called with the name of the flag and the recovered value. After the function returns, the panic
+// continues with the recovered value.
+//
+// A nil value removes the handler.
+func (s *FlagSet) SetPanicHandler(fn func(name string, recovered any)) {
+	defer s.updateHooked()
+
+	if fn == nil {
+		s.panicHandler.Store(nil)
+	} else {
+		s.panicHandler.Store(&fn)
+	}
+}
+
 // SetRegistry sets the Registry to be used for looking up flag values.
 //
 // A nil value will cause all flags to return zero values.
@@ -88,108 +994,437 @@ func (s *FlagSet) SetRegistry(r Registry) {
 	}
 }
 
-func (s *FlagSet) add(name string, fun any, opts ...Option) {
-	f := Flag{Name: name, Func: fun}
-	for _, opt := range opts {
-		opt(&f)
+func (s *FlagSet) normalize(name string) string {
+	fn := s.normalizer.Load()
+	if fn == nil {
+		return name
 	}
+	return (*fn)(name)
+}
 
+func (s *FlagSet) add(f Flag) {
 	s.flagsMu.Lock()
 	defer s.flagsMu.Unlock()
 
-	if _, ok := s.flags.m[f.Name]; ok {
-		panic(fmt.Errorf("%w: %s", ErrDuplicateFlag, f.Name))
+	if err := s.checkLocked(s.flags.m, f); err != nil {
+		panic(err)
 	}
 
 	s.flags = s.flags.add(f.Name, f)
 }
 
-// Bool registers a new flag that represents a boolean value.
-//
-// If a [Flag] with the same name is already registered, the call will panic with an error that is [ErrDuplicateFlag].
-func (s *FlagSet) Bool(name string, opts ...Option) func(context.Context) bool {
-	f := func(ctx context.Context) bool {
-		r := s.registry.Load()
-		if r == nil {
+// checkLocked returns an error if the given flag can not be added to the set, assuming the set contains the given
+// flags.
+func (s *FlagSet) checkLocked(flags map[string]Flag, f Flag) error {
+	if s.frozen {
+		return fmt.Errorf("%w: %s", ErrFrozen, f.Name)
+	}
+
+	if !slices.Contains(allKinds, f.Kind) {
+		return fmt.Errorf("%w: %s for flag %s", ErrInvalidKind, f.Kind, f.Name)
+	}
+
+	if _, ok := flags[f.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateFlag, f.Name)
+	}
+
+	if _, ok := s.aliases[f.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateFlag, f.Name)
+	}
+
+	if s.cycleLocked(flags, f.Name, f.Requires) {
+		return fmt.Errorf("%w: %s", ErrDependencyCycle, f.Name)
+	}
+
+	requires := f.Requires
+	if target, ok := s.aliases[requires]; ok {
+		requires = target
+	}
+
+	if parent, ok := flags[requires]; ok && parent.Kind != FlagKindBool {
+		return fmt.Errorf("%w: %s requires %s flag %s", ErrInvalidDependency, f.Name, parent.Kind, parent.Name)
+	}
+
+	if f.Kind != FlagKindBool {
+		if child, ok := requiredBy(flags, f.Name); ok {
+			return fmt.Errorf("%w: %s requires %s flag %s", ErrInvalidDependency, child.Name, f.Kind, f.Name)
+		}
+	}
+
+	return nil
+}
+
+// requiredBy returns a flag that depends on the flag with the given name, if any.
+func requiredBy(flags map[string]Flag, name string) (Flag, bool) {
+	for _, f := range flags {
+		if f.Requires == name {
+			return f, true
+		}
+	}
+
+	return Flag{}, false
+}
+
+// cycleLocked reports whether following the dependencies starting at the flag requires leads to the flag name.
+func (s *FlagSet) cycleLocked(flags map[string]Flag, name, requires string) bool {
+	for requires != "" {
+		if target, ok := s.aliases[requires]; ok {
+			requires = target
+		}
+
+		if requires == name {
+			return true
+		}
+
+		parent, ok := flags[requires]
+		if !ok {
 			return false
 		}
-		return (*r).Bool(ctx, name)
+
+		requires = parent.Requires
 	}
 
-	s.add(name, f, opts...)
+	return false
+}
 
-	return f
+// enabled returns the value of the boolean flag with the given name or false if there is no such flag.
+//
+// Once the flag is found, its function is cached in parent and used for all further calls.
+func (s *FlagSet) enabled(ctx context.Context, name string, parent *atomic.Pointer[func(context.Context) bool]) bool {
+	if fn := parent.Load(); fn != nil {
+		return (*fn)(ctx)
+	}
+
+	f, ok := s.Lookup(name)
+	if !ok {
+		return false
+	}
+
+	fn, ok := f.Func.(func(context.Context) bool)
+	if !ok {
+		return false
+	}
+
+	parent.Store(&fn)
+
+	return fn(ctx)
 }
 
-// Float registers a new flag that represents a float value.
+func lookup[T any](ctx context.Context, s *FlagSet, name string, get func(Registry, context.Context, string) T) T {
+	r := s.registry.Load()
+	if r == nil {
+		var zero T
+		return zero
+	}
+
+	if s.panicHandler.Load() != nil || s.latencyObserver.Load() != nil {
+		return lookupHooked(ctx, s, *r, name, get)
+	}
+
+	return get(*r, ctx, name)
+}
+
+// lookupHooked is like lookup, but also calls the panic handler and latency observer, if set.
 //
-// If a [Flag] with the same name is already registered, the call will panic with an error that is [ErrDuplicateFlag].
-func (s *FlagSet) Float(name string, opts ...Option) func(context.Context) float64 {
-	f := func(ctx context.Context) float64 {
-		r := s.registry.Load()
-		if r == nil {
-			return 0.0
+// This is kept separate from lookup so that reads without hooks do not have to set up a deferred call and lookup stays
+// small enough to be inlined.
+//
+//go:noinline
+func lookupHooked[T any](
+	ctx context.Context,
+	s *FlagSet,
+	r Registry,
+	name string,
+	get func(Registry, context.Context, string) T,
+) T {
+	if h := s.panicHandler.Load(); h != nil {
+		defer func() {
+			if rec := recover(); rec != nil {
+				(*h)(name, rec)
+				panic(rec)
+			}
+		}()
+	}
+
+	obs := s.latencyObserver.Load()
+	if obs == nil {
+		return get(r, ctx, name)
+	}
+
+	start := time.Now()
+	v := get(r, ctx, name)
+	(*obs)(name, time.Since(start))
+	return v
+}
+
+// updateHooked updates s.hooked after one of the optional features that observe flag reads was changed.
+func (s *FlagSet) updateHooked() {
+	s.hooked.Store(s.latencyObserver.Load() != nil ||
+		s.panicHandler.Load() != nil ||
+		s.audit.Load() != nil ||
+		s.lastValues.Load() != nil)
+}
+
+// flagReader reads the value of a single flag, taking into account dependencies and all optional features.
+//
+// Flag functions only use it when a dependency or one of the optional features is in use and otherwise call the
+// [Registry] directly.
+type flagReader[T comparable] struct {
+	s        *FlagSet
+	name     string
+	requires string
+	get      func(Registry, context.Context, string) T
+	parent   atomic.Pointer[func(context.Context) bool]
+}
+
+// direct reports whether the flag can be read by calling the [Registry] directly.
+func (rd *flagReader[T]) direct() bool {
+	return rd.requires == "" && !rd.s.hooked.Load()
+}
+
+func (rd *flagReader[T]) read(ctx context.Context) T {
+	var v T
+	if rd.requires == "" || rd.s.enabled(ctx, rd.requires, &rd.parent) {
+		v = lookup(ctx, rd.s, rd.name, rd.get)
+	}
+	if l := rd.s.lastValues.Load(); l != nil {
+		recordLastValue(l, rd.name, v)
+	}
+	if a := rd.s.audit.Load(); a != nil {
+		a.record(rd.name, v)
+	}
+	return v
+}
+
+// newFlag creates a new flag with the given name, kind and options as well as a reader for its value.
+func newFlag[T comparable](
+	s *FlagSet,
+	name string,
+	kind FlagKind,
+	get func(Registry, context.Context, string) T,
+	opts []Option,
+) (Flag, *flagReader[T]) {
+	name = s.normalize(name)
+
+	fl := Flag{Kind: kind, Name: name}
+	for _, opt := range opts {
+		opt(&fl)
+	}
+
+	if fl.Requires != "" {
+		fl.Requires = s.normalize(fl.Requires)
+	}
+
+	return fl, &flagReader[T]{s: s, name: name, requires: fl.Requires, get: get}
+}
+
+// register sets fn as function of the given flag and adds the flag to s.
+func register[T any](s *FlagSet, fl Flag, fn func(context.Context) T) func(context.Context) T {
+	fl.Func = fn
+
+	if s.captureCallers.Load() && fl.DefinedAt == "" {
+		fl.DefinedAt = definedAt()
+	}
+
+	s.add(fl)
+
+	return fn
+}
+
+// packagePrefix is the prefix of the names of all functions in this package.
+var packagePrefix = reflect.TypeFor[FlagSet]().PkgPath() + "."
+
+// definedAt returns the location of the first caller outside this package in the form "file:line".
+//
+// This skips functions like [RegisterStruct] or [LoadSpecs] that register flags on behalf of the user.
+func definedAt() string {
+	var pcs [32]uintptr
+
+	// Skip runtime.Callers and definedAt
+	n := runtime.Callers(2, pcs[:])
+
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+
+		if !strings.HasPrefix(frame.Function, packagePrefix) {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+
+		if !more {
+			return ""
 		}
-		return (*r).Float(ctx, name)
 	}
+}
 
-	s.add(name, f, opts...)
+// Bool registers a new flag that represents a boolean value.
+//
+// If a [Flag] with the same name is already registered, the call will panic with an error that is [ErrDuplicateFlag].
+func (s *FlagSet) Bool(name string, opts ...Option) func(context.Context) bool {
+	fl, rd := newFlag(s, name, FlagKindBool, Registry.Bool, opts)
 
-	return f
+	return register(s, fl, func(ctx context.Context) bool {
+		if r := s.registry.Load(); r != nil && rd.direct() {
+			return (*r).Bool(ctx, rd.name)
+		}
+		return rd.read(ctx)
+	})
 }
 
-// Int registers a new flag that represents an int64 value.
+// Float registers a new flag that represents a float value.
 //
 // If a [Flag] with the same name is already registered, the call will panic with an error that is [ErrDuplicateFlag].
-func (s *FlagSet) Int(name string, opts ...Option) func(context.Context) int64 {
-	f := func(ctx context.Context) int64 {
-		r := s.registry.Load()
-		if r == nil {
-			return 0
+func (s *FlagSet) Float(name string, opts ...Option) func(context.Context) float64 {
+	fl, rd := newFlag(s, name, FlagKindFloat, Registry.Float, opts)
+
+	return register(s, fl, func(ctx context.Context) float64 {
+		if r := s.registry.Load(); r != nil && rd.direct() {
+			return (*r).Float(ctx, rd.name)
 		}
-		return (*r).Int(ctx, name)
-	}
+		return rd.read(ctx)
+	})
+}
 
-	s.add(name, f, opts...)
+// Int registers a new flag that represents an int64 value.
+//
+// If a [Flag] with the same name is already registered, the call will panic with an error that is [ErrDuplicateFlag].
+func (s *FlagSet) Int(name string, opts ...Option) func(context.Context) int64 {
+	fl, rd := newFlag(s, name, FlagKindInt, Registry.Int, opts)
 
-	return f
+	return register(s, fl, func(ctx context.Context) int64 {
+		if r := s.registry.Load(); r != nil && rd.direct() {
+			return (*r).Int(ctx, rd.name)
+		}
+		return rd.read(ctx)
+	})
 }
 
 // String registers a new flag that represents a string value.
 //
 // If a [Flag] with the same name is already registered, the call will panic with an error that is [ErrDuplicateFlag].
 func (s *FlagSet) String(name string, opts ...Option) func(context.Context) string {
-	f := func(ctx context.Context) string {
-		r := s.registry.Load()
-		if r == nil {
-			return ""
-		}
-		return (*r).String(ctx, name)
-	}
+	fl, rd := newFlag(s, name, FlagKindString, Registry.String, opts)
 
-	s.add(name, f, opts...)
-
-	return f
+	return register(s, fl, func(ctx context.Context) string {
+		if r := s.registry.Load(); r != nil && rd.direct() {
+			return (*r).String(ctx, rd.name)
+		}
+		return rd.read(ctx)
+	})
 }
 
 // Uint registers a new flag that represents an uint64 value.
 //
 // If a [Flag] with the same name is already registered, the call will panic with an error that is [ErrDuplicateFlag].
 func (s *FlagSet) Uint(name string, opts ...Option) func(context.Context) uint64 {
-	f := func(ctx context.Context) uint64 {
-		r := s.registry.Load()
-		if r == nil {
-			return 0
+	fl, rd := newFlag(s, name, FlagKindUint, Registry.Uint, opts)
+
+	return register(s, fl, func(ctx context.Context) uint64 {
+		if r := s.registry.Load(); r != nil && rd.direct() {
+			return (*r).Uint(ctx, rd.name)
+		}
+		return rd.read(ctx)
+	})
+}
+
+// Spec describes a flag independent of any [FlagSet].
+type Spec struct {
+	// Kind is the kind of value of the flag.
+	Kind FlagKind
+
+	// Name is the name of the flag.
+	Name string
+
+	// Description is an optional description for the flag.
+	Description string
+
+	// Labels contains optional labels for the flag.
+	Labels map[string]string
+
+	// Requires is the optional name of a boolean flag this flag depends on. See [WithRequires].
+	Requires string
+}
+
+// LoadSpecs creates a new [FlagSet] and registers a flag for each of the given specs.
+//
+// If multiple specs share the same name, an error that is [ErrDuplicateFlag] is returned. If the dependencies of the
+// specs form a cycle, an error that is [ErrDependencyCycle] is returned. If a spec has an invalid kind, an error that
+// is [ErrInvalidKind] is returned.
+func LoadSpecs(specs []Spec) (*FlagSet, error) {
+	s := &FlagSet{}
+
+	for _, spec := range specs {
+		if err := s.registerSpec(spec); err != nil {
+			return nil, err
 		}
-		return (*r).Uint(ctx, name)
 	}
 
-	s.add(name, f, opts...)
+	return s, nil
+}
 
+// specFlag returns a flag with the normalized name, kind and dependency of spec, used for checking the spec.
+func (s *FlagSet) specFlag(spec Spec) Flag {
+	f := Flag{Kind: spec.Kind, Name: s.normalize(spec.Name)}
+	if spec.Requires != "" {
+		f.Requires = s.normalize(spec.Requires)
+	}
 	return f
 }
 
-// Option defines options for new flags which can be passed to [Register].
+// checkSpecsLocked returns an error if any of the given specs can not be registered, taking into account the flags
+// registered for the specs before it.
+func (s *FlagSet) checkSpecsLocked(specs []Spec) error {
+	flags := maps.Clone(s.flags.m)
+	if flags == nil {
+		flags = make(map[string]Flag, len(specs))
+	}
+
+	for _, spec := range specs {
+		f := s.specFlag(spec)
+
+		if err := s.checkLocked(flags, f); err != nil {
+			return err
+		}
+
+		flags[f.Name] = f
+	}
+
+	return nil
+}
+
+func (s *FlagSet) registerSpec(spec Spec, opts ...Option) error {
+	s.flagsMu.Lock()
+	err := s.checkLocked(s.flags.m, s.specFlag(spec))
+	s.flagsMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	opts = append(opts, WithDescription(spec.Description), WithLabels(spec.Labels), WithRequires(spec.Requires))
+
+	switch spec.Kind {
+	case FlagKindBool:
+		s.Bool(spec.Name, opts...)
+	case FlagKindFloat:
+		s.Float(spec.Name, opts...)
+	case FlagKindInt:
+		s.Int(spec.Name, opts...)
+	case FlagKindString:
+		s.String(spec.Name, opts...)
+	case FlagKindUint:
+		s.Uint(spec.Name, opts...)
+	case FlagKindInvalid:
+		fallthrough
+	default:
+		return fmt.Errorf("%w: %s for flag %s", ErrInvalidKind, spec.Kind, spec.Name)
+	}
+
+	return nil
+}
+
+// Option defines options for new flags which can be passed to the registration methods like [FlagSet.Bool].
 type Option func(*Flag)
 
 // WithDescription sets the description for a flag.
@@ -217,6 +1452,26 @@ func WithLabels(labels map[string]string) Option {
 	}
 }
 
+// withDefinedAt sets [Flag.DefinedAt] to the given location instead of the location of the registration.
+func withDefinedAt(loc string) Option {
+	return func(f *Flag) {
+		f.DefinedAt = loc
+	}
+}
+
+// WithRequires makes the flag depend on the boolean flag with the given name.
+//
+// If the parent flag is disabled or not registered, the flag returns its zero value without consulting the [Registry].
+//
+// If the dependencies form a cycle, registering the flag that closes the cycle will panic with an error that is
+// [ErrDependencyCycle]. If the parent flag is not a boolean flag, registering the flag that is registered last of the
+// two will panic with an error that is [ErrInvalidDependency].
+func WithRequires(parentName string) Option {
+	return func(f *Flag) {
+		f.Requires = parentName
+	}
+}
+
 // Registry defines method for getting the feature flag values by name.
 type Registry interface {
 	// Bool returns the boolean value for the flag with the given name.
@@ -3,7 +3,17 @@ package feature_test
 import (
 	"context"
 	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -61,26 +71,937 @@ func TestFlagSet_All(t *testing.T) {
 	assertEquals(t, want, slicesCollect(set.All), "")
 }
 
+func TestFlagSet_Alias(t *testing.T) {
+	t.Run("Alias", func(t *testing.T) {
+		ctx := context.Background()
+
+		var set feature.FlagSet
+		set.SetRegistry(testRegistry)
+
+		set.Bool("new-name", feature.WithDescription("description"))
+
+		if err := set.Alias("old-name", "new-name"); err != nil {
+			t.Fatalf("failed to create alias: %s", err)
+		}
+
+		f := mustLookup(t, &set, "old-name")
+		assertEquals(t, "new-name", f.Name, "alias resolved to wrong flag")
+
+		v, _ := f.Bool(ctx)
+		assertEquals(t, true, v, "")
+
+		if err := set.SetDescription("old-name", "updated"); err != nil {
+			t.Fatalf("failed to set description via alias: %s", err)
+		}
+
+		assertEquals(t, "updated", mustLookup(t, &set, "new-name").Description, "description not updated via alias")
+		assertEquals(t, []string{"new-name"}, slicesCollect(func(yield func(string) bool) {
+			set.All(func(f feature.Flag) bool { return yield(f.Name) })
+		}), "alias returned by All")
+
+		assertPanic(t, feature.ErrDuplicateFlag, func() {
+			set.String("old-name")
+		})
+	})
+
+	t.Run("Existing flag", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Bool("a")
+		set.Bool("b")
+
+		if err := set.Alias("a", "b"); !errors.Is(err, feature.ErrDuplicateFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrDuplicateFlag, err)
+		}
+	})
+
+	t.Run("Existing alias", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Bool("a")
+		set.Bool("b")
+
+		if err := set.Alias("c", "a"); err != nil {
+			t.Fatalf("failed to create alias: %s", err)
+		}
+
+		if err := set.Alias("c", "b"); !errors.Is(err, feature.ErrDuplicateFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrDuplicateFlag, err)
+		}
+	})
+
+	t.Run("Dependency cycle", func(t *testing.T) {
+		ctx := context.Background()
+
+		var set feature.FlagSet
+		set.SetRegistry(testRegistry)
+
+		a := set.Bool("a", feature.WithRequires("x"))
+		set.Bool("b", feature.WithRequires("a"))
+
+		if err := set.Alias("x", "b"); !errors.Is(err, feature.ErrDependencyCycle) {
+			t.Errorf("expected error %q, got %q", feature.ErrDependencyCycle, err)
+		}
+
+		if _, ok := set.Lookup("x"); ok {
+			t.Error("alias was registered despite error")
+		}
+
+		assertEquals(t, false, a(ctx), "")
+	})
+
+	t.Run("Unknown flag", func(t *testing.T) {
+		var set feature.FlagSet
+
+		if err := set.Alias("a", "b"); !errors.Is(err, feature.ErrUnknownFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrUnknownFlag, err)
+		}
+	})
+}
+
+func TestFlagSet_Or(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+	set.SetRegistry(testRegistry)
+
+	set.Bool("bool")
+	set.Float("float")
+	set.Int("int")
+	set.String("string")
+	set.Uint("uint")
+
+	t.Run("Bool", func(t *testing.T) {
+		assertEquals(t, true, set.BoolOr(ctx, "bool", false), "registered")
+		assertEquals(t, true, set.BoolOr(ctx, "unknown", true), "unregistered")
+		assertEquals(t, true, set.BoolOr(ctx, "string", true), "wrong kind")
+	})
+
+	t.Run("Float", func(t *testing.T) {
+		assertEquals(t, 2.5, set.FloatOr(ctx, "float", 1.5), "registered")
+		assertEquals(t, 1.5, set.FloatOr(ctx, "unknown", 1.5), "unregistered")
+		assertEquals(t, 1.5, set.FloatOr(ctx, "int", 1.5), "wrong kind")
+	})
+
+	t.Run("Int", func(t *testing.T) {
+		assertEquals(t, 1, set.IntOr(ctx, "int", 5), "registered")
+		assertEquals(t, 5, set.IntOr(ctx, "unknown", 5), "unregistered")
+		assertEquals(t, 5, set.IntOr(ctx, "uint", 5), "wrong kind")
+	})
+
+	t.Run("String", func(t *testing.T) {
+		assertEquals(t, "string", set.StringOr(ctx, "string", "fallback"), "registered")
+		assertEquals(t, "fallback", set.StringOr(ctx, "unknown", "fallback"), "unregistered")
+		assertEquals(t, "fallback", set.StringOr(ctx, "bool", "fallback"), "wrong kind")
+	})
+
+	t.Run("Uint", func(t *testing.T) {
+		assertEquals(t, 2, set.UintOr(ctx, "uint", 5), "registered")
+		assertEquals(t, 5, set.UintOr(ctx, "unknown", 5), "unregistered")
+		assertEquals(t, 5, set.UintOr(ctx, "float", 5), "wrong kind")
+	})
+}
+
+func TestFlagSet_BoolSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+
+	set.SetRegistry(&feature.SimpleRegistry{BoolFunc: func(_ context.Context, name string) bool {
+		return name != "b"
+	}})
+
+	set.Bool("c")
+	set.String("string")
+	set.Bool("a")
+	set.Int("int")
+	set.Bool("b")
+
+	names, bits := set.BoolSnapshot(ctx)
+
+	assertEquals(t, []string{"a", "b", "c"}, names, "names mismatch")
+	assertEquals(t, []bool{true, false, true}, bits, "values mismatch")
+}
+
+func TestFlagSet_EnvConflicts(t *testing.T) {
+	var set feature.FlagSet
+
+	set.Bool("new.ui")
+	set.Bool("new_ui")
+	set.Bool("New-UI")
+	set.Int("limit")
+	set.String("search.backend")
+
+	assertEquals(t, map[string][]string{
+		"APP_NEW_UI": {"New-UI", "new.ui", "new_ui"},
+	}, set.EnvConflicts("APP_"), "")
+}
+
+func TestFlagSet_EvaluateAll(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+	set.SetRegistry(testRegistry)
+
+	set.Bool("bool")
+	set.Int("int")
+	set.String("string")
+
+	t.Run("Known", func(t *testing.T) {
+		values, err := set.EvaluateAll(ctx, "bool", "string")
+		if err != nil {
+			t.Fatalf("failed to evaluate flags: %s", err)
+		}
+
+		assertEquals(t, map[string]any{"bool": true, "string": "string"}, values, "")
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		values, err := set.EvaluateAll(ctx, "bool", "unknown")
+		if !errors.Is(err, feature.ErrUnknownFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrUnknownFlag, err)
+		}
+
+		assertEquals(t, nil, values, "")
+	})
+}
+
+func TestFlagSet_Export(t *testing.T) {
+	var set feature.FlagSet
+
+	set.String("string", feature.WithDescription("string value"))
+	set.Bool("bool", feature.WithDescription("bool value"), feature.WithLabel("type", "bool"))
+	set.Uint("uint", feature.WithRequires("bool"))
+
+	specs := set.Export()
+
+	assertEquals(t, []feature.Spec{
+		{Kind: feature.FlagKindBool, Name: "bool", Description: "bool value", Labels: map[string]string{"type": "bool"}},
+		{Kind: feature.FlagKindString, Name: "string", Description: "string value"},
+		{Kind: feature.FlagKindUint, Name: "uint", Requires: "bool"},
+	}, specs, "")
+
+	loaded, err := feature.LoadSpecs(specs)
+	if err != nil {
+		t.Fatalf("failed to load specs: %s", err)
+	}
+
+	assertEquals(t, slicesCollect(set.All), slicesCollect(loaded.All), "")
+	assertEquals(t, specs, loaded.Export(), "")
+	assertEquals(t, feature.FlagKindString, mustLookup(t, loaded, "string").Kind, "kind mismatch")
+}
+
+func TestLoadSpecs(t *testing.T) {
+	t.Run("Duplicate", func(t *testing.T) {
+		_, err := feature.LoadSpecs([]feature.Spec{
+			{Kind: feature.FlagKindBool, Name: "test"},
+			{Kind: feature.FlagKindInt, Name: "test"},
+		})
+		if !errors.Is(err, feature.ErrDuplicateFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrDuplicateFlag, err)
+		}
+	})
+
+	t.Run("Dependency cycle", func(t *testing.T) {
+		_, err := feature.LoadSpecs([]feature.Spec{
+			{Kind: feature.FlagKindBool, Name: "a", Requires: "b"},
+			{Kind: feature.FlagKindBool, Name: "b", Requires: "a"},
+		})
+		if !errors.Is(err, feature.ErrDependencyCycle) {
+			t.Errorf("expected error %q, got %q", feature.ErrDependencyCycle, err)
+		}
+	})
+
+	t.Run("Non-bool parent", func(t *testing.T) {
+		for _, specs := range [][]feature.Spec{
+			{
+				{Kind: feature.FlagKindInt, Name: "parent"},
+				{Kind: feature.FlagKindBool, Name: "child", Requires: "parent"},
+			},
+			{
+				{Kind: feature.FlagKindBool, Name: "child", Requires: "parent"},
+				{Kind: feature.FlagKindInt, Name: "parent"},
+			},
+		} {
+			_, err := feature.LoadSpecs(specs)
+			if !errors.Is(err, feature.ErrInvalidDependency) {
+				t.Errorf("expected error %q, got %q", feature.ErrInvalidDependency, err)
+			}
+		}
+	})
+
+	t.Run("Invalid kind", func(t *testing.T) {
+		_, err := feature.LoadSpecs([]feature.Spec{{Name: "test"}})
+		if !errors.Is(err, feature.ErrInvalidKind) {
+			t.Errorf("expected error %q, got %q", feature.ErrInvalidKind, err)
+		}
+
+		assertEquals(t, "invalid kind: FlagKind(0) for flag test", err.Error(), "")
+	})
+}
+
+func TestFlagSet_Freeze(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+	set.SetRegistry(testRegistry)
+
+	f := set.Bool("before")
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			set.Freeze()
+		}()
+	}
+
+	wg.Wait()
+
+	assertPanic(t, feature.ErrFrozen, func() {
+		set.Bool("after")
+	})
+
+	assertEquals(t, true, f(ctx), "")
+	assertEquals(t, "before", mustLookup(t, &set, "before").Name, "")
+
+	_, ok := set.Lookup("after")
+	assertEquals(t, false, ok, "flag registered after freeze")
+}
+
+func TestFlagSet_Groups(t *testing.T) {
+	var set feature.FlagSet
+
+	set.Bool("payments.refund.enabled")
+	set.Int("payments.refund.limit")
+	set.Bool("payments.checkout")
+	set.String("search.backend")
+	set.Bool("maintenance")
+
+	lookup := func(names ...string) []feature.Flag {
+		flags := make([]feature.Flag, len(names))
+		for i, name := range names {
+			flags[i] = mustLookup(t, &set, name)
+		}
+		return flags
+	}
+
+	assertEquals(t, map[string][]feature.Flag{
+		"":                lookup("maintenance"),
+		"payments":        lookup("payments.checkout"),
+		"payments.refund": lookup("payments.refund.enabled", "payments.refund.limit"),
+		"search":          lookup("search.backend"),
+	}, set.Groups("."), "")
+}
+
+func TestFlagSet_LastValues(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+
+	b := set.Bool("bool")
+	i := set.Int("int")
+	set.String("string")
+
+	_ = b(ctx)
+
+	assertEquals(t, map[string]any{}, set.LastValues(), "values while disabled")
+
+	set.EnableLastValues(true)
+
+	assertEquals(t, map[string]any{}, set.LastValues(), "values before first read")
+
+	_ = b(ctx)
+	_ = i(ctx)
+
+	assertEquals(t, map[string]any{"bool": false, "int": int64(0)}, set.LastValues(), "values after first read")
+
+	set.SetRegistry(testRegistry)
+
+	_ = b(ctx)
+
+	assertEquals(t, map[string]any{"bool": true, "int": int64(0)}, set.LastValues(), "values after second read")
+
+	set.EnableLastValues(false)
+
+	assertEquals(t, map[string]any{}, set.LastValues(), "values after disabling")
+}
+
+func TestFlagSet_JSONSchema(t *testing.T) {
+	var set feature.FlagSet
+
+	set.Bool("bool", feature.WithDescription("bool value"))
+	set.Float("float")
+	set.Int("int")
+	set.String("string")
+	set.Uint("uint")
+
+	got, err := set.JSONSchema()
+	if err != nil {
+		t.Fatalf("failed to create schema: %s", err)
+	}
+
+	want := `{` +
+		`"$schema":"https://json-schema.org/draft/2020-12/schema",` +
+		`"additionalProperties":false,` +
+		`"properties":{` +
+		`"bool":{"description":"bool value","type":"boolean"},` +
+		`"float":{"type":"number"},` +
+		`"int":{"type":"integer"},` +
+		`"string":{"type":"string"},` +
+		`"uint":{"minimum":0,"type":"integer"}` +
+		`},` +
+		`"type":"object"` +
+		`}`
+
+	assertEquals(t, want, string(got), "")
+}
+
 func TestFlagSet_Lookup(t *testing.T) {
 	var set feature.FlagSet
 
-	set.Bool("flagA")
-	set.Bool("flagB", feature.WithDescription("description"))
+	set.Bool("flagA")
+	set.Bool("flagB", feature.WithDescription("description"))
+
+	flagA, okA := set.Lookup("flagA")
+	assertEquals(t, "flagA", flagA.Name, "flagA name mismatch")
+	assertEquals(t, "", flagA.Description, "flagA description mismatch")
+	assertEquals(t, true, okA, "flagA not marked as ok")
+
+	flagB, okB := set.Lookup("flagB")
+	assertEquals(t, "flagB", flagB.Name, "flagB name mismatch")
+	assertEquals(t, "description", flagB.Description, "flagB name mismatch")
+	assertEquals(t, true, okB, "flagB not marked as ok")
+
+	flagC, okC := set.Lookup("flagC")
+	assertEquals(t, "", flagC.Name, "flagC name mismatch")
+	assertEquals(t, "", flagC.Description, "flagC name mismatch")
+	assertEquals(t, false, okC, "flagC marked as ok")
+}
+
+func TestFlagSet_Match(t *testing.T) {
+	var set feature.FlagSet
+
+	set.Bool("payments.refund")
+	set.Bool("payments.checkout")
+	set.Bool("payments")
+	set.Bool("search.new-ui")
+
+	names := func(pattern string) []string {
+		var names []string
+		set.Match(pattern)(func(f feature.Flag) bool {
+			names = append(names, f.Name)
+			return true
+		})
+		return names
+	}
+
+	assertEquals(t, []string{"payments.checkout", "payments.refund"}, names("payments.*"), "prefix glob mismatch")
+	assertEquals(t, []string{"search.new-ui"}, names("search.new-ui"), "exact match mismatch")
+	assertEquals(t, nil, names("payments.["), "malformed pattern mismatch")
+}
+
+func TestFlagSet_RegisterLazy(t *testing.T) {
+	t.Run("Register", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Bool("eager")
+
+		var calls int
+
+		set.RegisterLazy(func() []feature.Spec {
+			calls++
+
+			return []feature.Spec{
+				{Kind: feature.FlagKindBool, Name: "lazy-bool", Description: "lazy bool"},
+				{Kind: feature.FlagKindString, Name: "lazy-string"},
+			}
+		})
+
+		assertEquals(t, 0, calls, "provider called before first access")
+
+		f := mustLookup(t, &set, "lazy-bool")
+		assertEquals(t, "lazy bool", f.Description, "")
+		assertEquals(t, feature.FlagKindBool, f.Kind, "")
+
+		flags := slicesCollect(set.All)
+		assertEquals(t, 3, len(flags), "unexpected number of flags")
+
+		assertEquals(t, 1, calls, "provider not called exactly once")
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Bool("test")
+
+		set.RegisterLazy(func() []feature.Spec {
+			return []feature.Spec{{Kind: feature.FlagKindBool, Name: "test"}}
+		})
+
+		assertPanic(t, feature.ErrDuplicateFlag, func() {
+			set.Lookup("test")
+		})
+	})
+
+	t.Run("Duplicate after valid spec", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Bool("dup")
+
+		set.RegisterLazy(func() []feature.Spec {
+			return []feature.Spec{
+				{Kind: feature.FlagKindBool, Name: "ok"},
+				{Kind: feature.FlagKindBool, Name: "dup"},
+			}
+		})
+
+		assertPanic(t, feature.ErrDuplicateFlag, func() {
+			set.Lookup("ok")
+		})
+
+		if _, ok := set.Lookup("ok"); ok {
+			t.Error("flag registered despite invalid provider")
+		}
+
+		assertEquals(t, 1, len(slicesCollect(set.All)), "unexpected number of flags")
+	})
+
+	t.Run("Defined at", func(t *testing.T) {
+		var set feature.FlagSet
+		set.SetCaptureCallers(true)
+
+		_, file, line, _ := runtime.Caller(0)
+		set.RegisterLazy(func() []feature.Spec {
+			return []feature.Spec{{Kind: feature.FlagKindBool, Name: "lazy"}}
+		})
+
+		assertEquals(t, file+":"+strconv.Itoa(line+1), mustLookup(t, &set, "lazy").DefinedAt, "")
+	})
+
+	t.Run("Freeze", func(t *testing.T) {
+		var set feature.FlagSet
+
+		set.RegisterLazy(func() []feature.Spec {
+			return []feature.Spec{{Kind: feature.FlagKindBool, Name: "lazy"}}
+		})
+
+		set.Freeze()
+
+		mustLookup(t, &set, "lazy")
+
+		assertPanic(t, feature.ErrFrozen, func() {
+			set.RegisterLazy(func() []feature.Spec { return nil })
+		})
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		errProvider := errors.New("provider failed")
+
+		var set feature.FlagSet
+
+		var calls int
+
+		set.RegisterLazy(func() []feature.Spec {
+			if calls++; calls == 1 {
+				panic(errProvider)
+			}
+			return []feature.Spec{{Kind: feature.FlagKindBool, Name: "a"}}
+		})
+
+		set.RegisterLazy(func() []feature.Spec {
+			return []feature.Spec{{Kind: feature.FlagKindBool, Name: "b"}}
+		})
+
+		assertPanic(t, errProvider, func() {
+			set.Lookup("a")
+		})
+
+		mustLookup(t, &set, "a")
+		mustLookup(t, &set, "b")
+
+		assertEquals(t, 2, calls, "")
+	})
+}
+
+func TestFlagSet_Require(t *testing.T) {
+	var set feature.FlagSet
+
+	set.Bool("a")
+	set.Int("b")
+
+	if err := set.Require("a", "b"); err != nil {
+		t.Errorf("expected no error, got %q", err)
+	}
+
+	err := set.Require("a", "c", "b", "d")
+	if !errors.Is(err, feature.ErrUnknownFlag) {
+		t.Errorf("expected error %q, got %q", feature.ErrUnknownFlag, err)
+	}
+
+	assertEquals(t, "unknown flag: c\nunknown flag: d", err.Error(), "")
+}
+
+func TestFlagSet_SchemaEqual(t *testing.T) {
+	newSet := func() *feature.FlagSet {
+		var set feature.FlagSet
+		set.Bool("bool", feature.WithDescription("bool value"))
+		set.Int("int", feature.WithDescription("int value"))
+		set.String("string")
+		return &set
+	}
+
+	t.Run("Equal", func(t *testing.T) {
+		equal, diffs := newSet().SchemaEqual(newSet())
+		assertEquals(t, true, equal, "")
+		assertEquals(t, nil, diffs, "")
+	})
+
+	t.Run("Different", func(t *testing.T) {
+		var other feature.FlagSet
+		other.Bool("bool", feature.WithDescription("new bool value"))
+		other.Uint("int", feature.WithDescription("int value"))
+		other.Float("float")
+
+		equal, diffs := newSet().SchemaEqual(&other)
+		assertEquals(t, false, equal, "")
+		assertEquals(t, []string{
+			`flag "bool" description changed from "bool value" to "new bool value"`,
+			`flag "float" added`,
+			`flag "int" kind changed from int to uint`,
+			`flag "string" removed`,
+		}, diffs, "")
+	})
+}
+
+func TestFlagSet_SchemaHash(t *testing.T) {
+	var setA, setB feature.FlagSet
+
+	setA.Bool("a", feature.WithDescription("flag a"))
+	setA.Int("b")
+
+	setB.Int("b")
+	setB.Bool("a", feature.WithDescription("flag a"))
+
+	hash := setA.SchemaHash()
+
+	assertEquals(t, hash, setB.SchemaHash(), "hash differs for identical sets")
+
+	setB.String("c")
+
+	if setB.SchemaHash() == hash {
+		t.Error("hash did not change after adding a flag")
+	}
+
+	if err := setA.SetDescription("a", "updated"); err != nil {
+		t.Fatalf("failed to set description: %s", err)
+	}
+
+	if setA.SchemaHash() == hash {
+		t.Error("hash did not change after changing a description")
+	}
+
+	t.Run("Unordered", func(t *testing.T) {
+		var setA, setB feature.FlagSet
+		setA.SetUnordered(true)
+		setB.SetUnordered(true)
+
+		setA.Bool("x")
+		setA.Bool("y")
+
+		setB.Bool("y")
+		setB.Bool("x")
+
+		assertEquals(t, setA.SchemaHash(), setB.SchemaHash(), "hash differs for identical sets")
+	})
+}
+
+func TestFlagSet_SetCaptureCallers(t *testing.T) {
+	var set feature.FlagSet
+
+	set.Bool("disabled")
 
-	flagA, okA := set.Lookup("flagA")
-	assertEquals(t, "flagA", flagA.Name, "flagA name mismatch")
-	assertEquals(t, "", flagA.Description, "flagA description mismatch")
-	assertEquals(t, true, okA, "flagA not marked as ok")
+	set.SetCaptureCallers(true)
 
-	flagB, okB := set.Lookup("flagB")
-	assertEquals(t, "flagB", flagB.Name, "flagB name mismatch")
-	assertEquals(t, "description", flagB.Description, "flagB name mismatch")
-	assertEquals(t, true, okB, "flagB not marked as ok")
+	_, file, line, _ := runtime.Caller(0)
+	set.Bool("enabled")
 
-	flagC, okC := set.Lookup("flagC")
-	assertEquals(t, "", flagC.Name, "flagC name mismatch")
-	assertEquals(t, "", flagC.Description, "flagC name mismatch")
-	assertEquals(t, false, okC, "flagC marked as ok")
+	set.SetCaptureCallers(false)
+
+	set.Bool("disabled-again")
+
+	assertEquals(t, "", mustLookup(t, &set, "disabled").DefinedAt, "")
+	assertEquals(t, file+":"+strconv.Itoa(line+1), mustLookup(t, &set, "enabled").DefinedAt, "")
+	assertEquals(t, "", mustLookup(t, &set, "disabled-again").DefinedAt, "")
+}
+
+func TestFlagSet_SetDescription(t *testing.T) {
+	var set feature.FlagSet
+
+	set.Bool("flag", feature.WithDescription("description"))
+
+	all := slicesCollect(set.All)
+
+	if err := set.SetDescription("flag", "new description"); err != nil {
+		t.Fatalf("failed to set description: %s", err)
+	}
+
+	assertEquals(t, "new description", mustLookup(t, &set, "flag").Description, "description not updated")
+	assertEquals(t, "description", all[0].Description, "previously returned flag modified")
+
+	if err := set.SetDescription("unknown", "description"); !errors.Is(err, feature.ErrUnknownFlag) {
+		t.Errorf("expected error %q, got %q", feature.ErrUnknownFlag, err)
+	}
+}
+
+func TestFlagSet_SetLatencyObserver(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+
+	set.SetRegistry(&feature.SimpleRegistry{IntFunc: func(context.Context, string) int64 {
+		time.Sleep(10 * time.Millisecond)
+		return 1
+	}})
+
+	f := set.Int("slow")
+
+	var (
+		names     []string
+		durations []time.Duration
+	)
+
+	set.SetLatencyObserver(func(name string, d time.Duration) {
+		names = append(names, name)
+		durations = append(durations, d)
+	})
+
+	assertEquals(t, 1, f(ctx), "")
+
+	set.SetLatencyObserver(nil)
+
+	assertEquals(t, 1, f(ctx), "")
+
+	assertEquals(t, []string{"slow"}, names, "")
+
+	if durations[0] < 10*time.Millisecond {
+		t.Errorf("expected duration of at least 10ms, got %s", durations[0])
+	}
+}
+
+func TestFlagSet_SetNameNormalizer(t *testing.T) {
+	normalize := func(name string) string {
+		return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	}
+
+	t.Run("Normalize", func(t *testing.T) {
+		ctx := context.Background()
+
+		var set feature.FlagSet
+		set.SetNameNormalizer(normalize)
+
+		var names []string
+
+		set.SetRegistry(&feature.SimpleRegistry{BoolFunc: func(_ context.Context, name string) bool {
+			names = append(names, name)
+			return true
+		}})
+
+		f := set.Bool("New_UI")
+
+		assertEquals(t, "new-ui", mustLookup(t, &set, "NEW_UI").Name, "lookup with different casing")
+		assertEquals(t, "new-ui", mustLookup(t, &set, "new-ui").Name, "lookup with normalized name")
+		assertEquals(t, true, f(ctx), "")
+		assertEquals(t, []string{"new-ui"}, names, "registry called with unnormalized name")
+
+		assertPanic(t, feature.ErrDuplicateFlag, func() {
+			set.String("new_ui")
+		})
+	})
+
+	t.Run("After registration", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Bool("test")
+
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic, call did not panic")
+			}
+		}()
+
+		set.SetNameNormalizer(normalize)
+	})
+
+	t.Run("Twice", func(t *testing.T) {
+		var set feature.FlagSet
+		set.SetNameNormalizer(normalize)
+
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic, call did not panic")
+			}
+		}()
+
+		set.SetNameNormalizer(normalize)
+	})
+}
+
+func TestFlagSet_SetPanicHandler(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+
+	set.SetRegistry(&feature.SimpleRegistry{BoolFunc: func(context.Context, string) bool {
+		panic("boom")
+	}})
+
+	f := set.Bool("panics")
+
+	var (
+		names     []string
+		recovered []any
+	)
+
+	set.SetPanicHandler(func(name string, r any) {
+		names = append(names, name)
+		recovered = append(recovered, r)
+	})
+
+	func() {
+		defer func() {
+			assertEquals(t, any("boom"), recover(), "panic not propagated")
+		}()
+
+		_ = f(ctx)
+	}()
+
+	assertEquals(t, []string{"panics"}, names, "")
+	assertEquals(t, []any{"boom"}, recovered, "")
+}
+
+func TestFlagSet_SetRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+
+	f := set.Bool("test")
+
+	registries := []feature.Registry{
+		nil,
+		&feature.SimpleRegistry{BoolFunc: func(context.Context, string) bool { return true }},
+		&feature.SimpleRegistry{BoolFunc: func(context.Context, string) bool { return false }},
+	}
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range 1000 {
+				_ = f(ctx)
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for i := range 1000 {
+			set.SetRegistry(registries[i%len(registries)])
+		}
+	}()
+
+	wg.Wait()
+}
+
+func TestFlagSet_SnapshotOrdered(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+	set.SetRegistry(testRegistry)
+
+	set.Uint("e")
+	set.String("d")
+	set.Bool("a")
+	set.Int("c")
+	set.Float("b")
+
+	assertEquals(t, []feature.FlagValue{
+		{Name: "a", Value: true},
+		{Name: "b", Value: 2.5},
+		{Name: "c", Value: int64(1)},
+		{Name: "d", Value: "string"},
+		{Name: "e", Value: uint64(2)},
+	}, set.SnapshotOrdered(ctx), "")
+
+	t.Run("Unordered", func(t *testing.T) {
+		var set feature.FlagSet
+		set.SetUnordered(true)
+
+		set.Bool("y")
+		set.Bool("x")
+
+		assertEquals(t, []feature.FlagValue{
+			{Name: "x", Value: false},
+			{Name: "y", Value: false},
+		}, set.SnapshotOrdered(ctx), "")
+	})
+}
+
+func TestFlagSet_TemplateFuncs(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+	set.SetRegistry(testRegistry)
+
+	set.Bool("new-ui")
+	set.Int("limit")
+
+	execute := func(text string) (string, error) {
+		tmpl := template.Must(template.New("test").Funcs(set.TemplateFuncs(ctx)).Parse(text))
+
+		var buf strings.Builder
+		err := tmpl.Execute(&buf, nil)
+		return buf.String(), err
+	}
+
+	t.Run("Known", func(t *testing.T) {
+		got, err := execute(`{{ if feature "new-ui" }}new{{ else }}old{{ end }} {{ feature "limit" }}`)
+		if err != nil {
+			t.Fatalf("failed to execute template: %s", err)
+		}
+
+		assertEquals(t, "new 1", got, "")
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := execute(`{{ feature "unknown" }}`)
+		if !errors.Is(err, feature.ErrUnknownFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrUnknownFlag, err)
+		}
+	})
+}
+
+func TestFlagSet_SetUnordered(t *testing.T) {
+	var set feature.FlagSet
+	set.SetUnordered(true)
+
+	set.Bool("c")
+	set.Int("a")
+	set.String("b")
+
+	assertEquals(t, "a", mustLookup(t, &set, "a").Name, "")
+	assertEquals(t, []string{"c", "a", "b"}, slicesCollect(func(yield func(string) bool) {
+		set.All(func(f feature.Flag) bool { return yield(f.Name) })
+	}), "flags not in insertion order")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic, call did not panic")
+		}
+	}()
+
+	set.SetUnordered(false)
 }
 
 func TestFlagSet_Bool(t *testing.T) {
@@ -263,6 +1184,218 @@ func TestFlagSet_Uint(t *testing.T) {
 	})
 }
 
+func TestFlag_Getters(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+	set.SetRegistry(testRegistry)
+
+	set.Bool("bool")
+	set.Float("float")
+	set.Int("int")
+	set.String("string")
+	set.Uint("uint")
+
+	t.Run("Bool", func(t *testing.T) {
+		v, ok := mustLookup(t, &set, "bool").Bool(ctx)
+		assertEquals(t, true, v, "value mismatch")
+		assertEquals(t, true, ok, "flag not marked as bool")
+
+		v, ok = mustLookup(t, &set, "string").Bool(ctx)
+		assertEquals(t, false, v, "value mismatch")
+		assertEquals(t, false, ok, "flag marked as bool")
+	})
+
+	t.Run("Float", func(t *testing.T) {
+		v, ok := mustLookup(t, &set, "float").Float(ctx)
+		assertEquals(t, 2.5, v, "value mismatch")
+		assertEquals(t, true, ok, "flag not marked as float")
+
+		v, ok = mustLookup(t, &set, "int").Float(ctx)
+		assertEquals(t, 0.0, v, "value mismatch")
+		assertEquals(t, false, ok, "flag marked as float")
+	})
+
+	t.Run("Int", func(t *testing.T) {
+		v, ok := mustLookup(t, &set, "int").Int(ctx)
+		assertEquals(t, 1, v, "value mismatch")
+		assertEquals(t, true, ok, "flag not marked as int")
+
+		v, ok = mustLookup(t, &set, "uint").Int(ctx)
+		assertEquals(t, 0, v, "value mismatch")
+		assertEquals(t, false, ok, "flag marked as int")
+	})
+
+	t.Run("String", func(t *testing.T) {
+		v, ok := mustLookup(t, &set, "string").String(ctx)
+		assertEquals(t, "string", v, "value mismatch")
+		assertEquals(t, true, ok, "flag not marked as string")
+
+		v, ok = mustLookup(t, &set, "bool").String(ctx)
+		assertEquals(t, "", v, "value mismatch")
+		assertEquals(t, false, ok, "flag marked as string")
+	})
+
+	t.Run("Uint", func(t *testing.T) {
+		v, ok := mustLookup(t, &set, "uint").Uint(ctx)
+		assertEquals(t, 2, v, "value mismatch")
+		assertEquals(t, true, ok, "flag not marked as uint")
+
+		v, ok = mustLookup(t, &set, "float").Uint(ctx)
+		assertEquals(t, 0, v, "value mismatch")
+		assertEquals(t, false, ok, "flag marked as uint")
+	})
+}
+
+func TestKinds(t *testing.T) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "feature.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse feature.go: %s", err)
+	}
+
+	var names []string
+
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.CONST {
+			continue
+		}
+
+		for _, spec := range decl.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				if strings.HasPrefix(name.Name, "FlagKind") && name.Name != "FlagKindInvalid" {
+					names = append(names, name.Name)
+				}
+			}
+		}
+	}
+
+	var kinds []string
+	for _, kind := range feature.Kinds() {
+		kinds = append(kinds, "FlagKind"+strings.ToUpper(kind.String()[:1])+kind.String()[1:])
+	}
+
+	slices.Sort(names)
+	slices.Sort(kinds)
+
+	assertEquals(t, names, kinds, "Kinds does not match declared FlagKind constants")
+}
+
+func TestFlagKind_String(t *testing.T) {
+	assertEquals(t, "bool", feature.FlagKindBool.String(), "")
+	assertEquals(t, "uint", feature.FlagKindUint.String(), "")
+	assertEquals(t, "FlagKind(0)", feature.FlagKindInvalid.String(), "")
+	assertEquals(t, "FlagKind(255)", feature.FlagKind(255).String(), "")
+}
+
+func TestWithRequires(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Dependency", func(t *testing.T) {
+		var set feature.FlagSet
+
+		parentEnabled, childEnabled := false, false
+
+		set.SetRegistry(&feature.SimpleRegistry{
+			BoolFunc: func(_ context.Context, name string) bool {
+				if name == "parent" {
+					return parentEnabled
+				}
+				return childEnabled
+			},
+			IntFunc: func(context.Context, string) int64 {
+				return 5
+			},
+		})
+
+		parent := set.Bool("parent")
+		child := set.Bool("child", feature.WithRequires("parent"))
+		limit := set.Int("limit", feature.WithRequires("child"))
+
+		assertEquals(t, "parent", mustLookup(t, &set, "child").Requires, "")
+
+		childEnabled = true
+
+		assertEquals(t, false, parent(ctx), "")
+		assertEquals(t, false, child(ctx), "child enabled with parent disabled")
+		assertEquals(t, 0, limit(ctx), "grandchild returned value with parent disabled")
+
+		parentEnabled = true
+
+		assertEquals(t, true, child(ctx), "child disabled with parent enabled")
+		assertEquals(t, 5, limit(ctx), "")
+
+		childEnabled = false
+
+		assertEquals(t, false, child(ctx), "child enabled despite being disabled")
+		assertEquals(t, 0, limit(ctx), "")
+	})
+
+	t.Run("Unknown parent", func(t *testing.T) {
+		var set feature.FlagSet
+		set.SetRegistry(testRegistry)
+
+		child := set.Bool("child", feature.WithRequires("parent"))
+
+		assertEquals(t, false, child(ctx), "child enabled with unknown parent")
+
+		set.Bool("parent")
+
+		assertEquals(t, true, child(ctx), "child disabled after registering parent")
+	})
+
+	t.Run("Non-bool parent", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Int("parent")
+
+		assertPanic(t, feature.ErrInvalidDependency, func() {
+			set.Bool("child", feature.WithRequires("parent"))
+		})
+	})
+
+	t.Run("Non-bool parent registered later", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Bool("child", feature.WithRequires("parent"))
+
+		assertPanic(t, feature.ErrInvalidDependency, func() {
+			set.Int("parent")
+		})
+
+		set.Bool("parent")
+	})
+
+	t.Run("Non-bool alias", func(t *testing.T) {
+		var set feature.FlagSet
+		set.Bool("child", feature.WithRequires("parent"))
+		set.Int("int")
+
+		if err := set.Alias("parent", "int"); !errors.Is(err, feature.ErrInvalidDependency) {
+			t.Errorf("expected error %q, got %q", feature.ErrInvalidDependency, err)
+		}
+
+		if _, ok := set.Lookup("parent"); ok {
+			t.Error("alias was registered despite error")
+		}
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		var set feature.FlagSet
+
+		set.Bool("a", feature.WithRequires("c"))
+		set.Bool("b", feature.WithRequires("a"))
+
+		assertPanic(t, feature.ErrDependencyCycle, func() {
+			set.Bool("c", feature.WithRequires("b"))
+		})
+
+		assertPanic(t, feature.ErrDependencyCycle, func() {
+			set.Bool("self", feature.WithRequires("self"))
+		})
+	})
+}
+
 func TestLabels(t *testing.T) {
 	var s feature.FlagSet
 
@@ -365,6 +1498,64 @@ func BenchmarkFlagSet_String(b *testing.B) {
 	}
 }
 
+func BenchmarkFlagSet_Register(b *testing.B) {
+	names := make([]string, 1000)
+	for i := range names {
+		names[i] = "flag" + strconv.Itoa(len(names)-i)
+	}
+
+	for _, unordered := range []bool{false, true} {
+		b.Run("Unordered="+strconv.FormatBool(unordered), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for range b.N {
+				var set feature.FlagSet
+				set.SetUnordered(unordered)
+
+				for _, name := range names {
+					set.Bool(name)
+				}
+			}
+		})
+	}
+}
+
+var globalValues map[string]any
+
+func BenchmarkFlagSet_EvaluateAll(b *testing.B) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+	set.SetRegistry(testRegistry)
+
+	names := make([]string, 20)
+	for i := range names {
+		names[i] = "flag" + strconv.Itoa(i)
+		set.Bool(names[i])
+	}
+
+	b.Run("EvaluateAll", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			globalValues, _ = set.EvaluateAll(ctx, names...)
+		}
+	})
+
+	b.Run("Lookup", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			values := make(map[string]any, len(names))
+			for _, name := range names {
+				f, _ := set.Lookup(name)
+				values[name], _ = f.Bool(ctx)
+			}
+			globalValues = values
+		}
+	})
+}
+
 func assertEquals[T any](tb testing.TB, want, got T, msg string) {
 	tb.Helper()
 
@@ -377,7 +1568,7 @@ func assertEquals[T any](tb testing.TB, want, got T, msg string) {
 	})
 
 	flagComparer := cmp.Comparer(func(x, y feature.Flag) bool {
-		return x.Name == y.Name && x.Description == y.Description && cmp.Equal(x.Labels, y.Labels, labelsComparer)
+		return x.Kind == y.Kind && x.Name == y.Name && x.Description == y.Description && cmp.Equal(x.Labels, y.Labels, labelsComparer)
 	})
 
 	if diff := cmp.Diff(want, got, flagComparer, labelsComparer); diff != "" {
@@ -0,0 +1,125 @@
+package feature
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Map returns a function that calls fn and returns the result converted using conv.
+//
+// This can be used to adapt flags to other types, for example to interpret an int flag as a [time.Duration].
+func Map[A, B any](fn func(context.Context) A, conv func(A) B) func(context.Context) B {
+	return func(ctx context.Context) B {
+		return conv(fn(ctx))
+	}
+}
+
+// Blend returns a function that interpolates between the results of a and b using the weight returned by ramp.
+//
+// The result is a*(1-w) + b*w, where w is the result of ramp clamped to the range [0, 1].
+func Blend(a, b func(context.Context) float64, ramp func(context.Context) float64) func(context.Context) float64 {
+	return func(ctx context.Context) float64 {
+		w := min(max(ramp(ctx), 0), 1)
+		return a(ctx)*(1-w) + b(ctx)*w
+	}
+}
+
+// Disabled returns a function that always returns the zero value of T.
+//
+// This can be used in place of the function of a removed flag.
+func Disabled[T any]() func(context.Context) T {
+	return func(context.Context) T {
+		var zero T
+		return zero
+	}
+}
+
+// FromPointer returns a function that returns the value currently stored in p.
+//
+// If p holds a nil pointer, the zero value of T is returned.
+func FromPointer[T any](p *atomic.Pointer[T]) func(context.Context) T {
+	return func(context.Context) T {
+		v := p.Load()
+		if v == nil {
+			var zero T
+			return zero
+		}
+		return *v
+	}
+}
+
+// ObserveFloat returns a function that calls fn and passes each result to obs before returning it.
+func ObserveFloat(fn func(context.Context) float64, obs func(float64)) func(context.Context) float64 {
+	return func(ctx context.Context) float64 {
+		v := fn(ctx)
+		obs(v)
+		return v
+	}
+}
+
+// OnChange returns a function that calls fn and calls hook if the result differs from the result of the previous call.
+//
+// The first call never calls hook.
+func OnChange[T comparable](fn func(context.Context) T, hook func(old, new T)) func(context.Context) T {
+	var last atomic.Pointer[T]
+
+	return func(ctx context.Context) T {
+		v := fn(ctx)
+
+		if old := last.Swap(&v); old != nil && *old != v {
+			hook(*old, v)
+		}
+
+		return v
+	}
+}
+
+// RateLimited returns a function that calls fn at most once per interval and otherwise returns the last result.
+//
+// Calls are serialized, so concurrent callers wait for a running call to fn instead of calling fn themselves. Since
+// the result is shared between all callers, it should not depend on the given context.
+//
+// If clock is nil, [time.Now] is used.
+func RateLimited[T any](fn func(context.Context) T, interval time.Duration, clock func() time.Time) func(context.Context) T {
+	if clock == nil {
+		clock = time.Now
+	}
+
+	var (
+		mu    sync.Mutex
+		last  time.Time
+		value T
+		valid bool
+	)
+
+	return func(ctx context.Context) T {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if now := clock(); !valid || now.Sub(last) >= interval {
+			value, last, valid = fn(ctx), now, true
+		}
+
+		return value
+	}
+}
+
+// Safe returns a function that calls fn and returns fallback if fn panics.
+//
+// If onPanic is not nil, it is called with the recovered value before returning.
+func Safe[T any](fn func(context.Context) T, fallback T, onPanic func(recovered any)) func(context.Context) T {
+	return func(ctx context.Context) (v T) {
+		defer func() {
+			if r := recover(); r != nil {
+				if onPanic != nil {
+					onPanic(r)
+				}
+				v = fallback
+			}
+		}()
+
+		return fn(ctx)
+	}
+}
@@ -0,0 +1,200 @@
+package feature_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nussjustin/feature"
+)
+
+func TestMap(t *testing.T) {
+	ctx := context.Background()
+
+	var set feature.FlagSet
+	set.SetRegistry(testRegistry)
+
+	t.Run("Duration", func(t *testing.T) {
+		f := feature.Map(set.Int("timeout"), func(v int64) time.Duration {
+			return time.Duration(v) * time.Second
+		})
+
+		assertEquals(t, time.Second, f(ctx), "")
+	})
+
+	t.Run("String", func(t *testing.T) {
+		f := feature.Map(set.String("name"), strings.ToUpper)
+
+		assertEquals(t, "STRING", f(ctx), "")
+	})
+}
+
+func TestBlend(t *testing.T) {
+	ctx := context.Background()
+
+	constant := func(v float64) func(context.Context) float64 {
+		return func(context.Context) float64 {
+			return v
+		}
+	}
+
+	for _, tc := range []struct {
+		Name string
+		W    float64
+		Want float64
+	}{
+		{Name: "Zero", W: 0, Want: 10},
+		{Name: "Half", W: 0.5, Want: 15},
+		{Name: "One", W: 1, Want: 20},
+		{Name: "Below zero", W: -1, Want: 10},
+		{Name: "Above one", W: 2, Want: 20},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			f := feature.Blend(constant(10), constant(20), constant(tc.W))
+
+			assertEquals(t, tc.Want, f(ctx), "")
+		})
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	type config struct {
+		Name  string
+		Limit int
+	}
+
+	assertEquals(t, false, feature.Disabled[bool]()(ctx), "")
+	assertEquals(t, "", feature.Disabled[string]()(ctx), "")
+	assertEquals(t, config{}, feature.Disabled[config]()(ctx), "")
+}
+
+func TestFromPointer(t *testing.T) {
+	ctx := context.Background()
+
+	var p atomic.Pointer[string]
+
+	f := feature.FromPointer(&p)
+
+	assertEquals(t, "", f(ctx), "")
+
+	one, two := "one", "two"
+
+	p.Store(&one)
+	assertEquals(t, "one", f(ctx), "")
+
+	p.Store(&two)
+	assertEquals(t, "two", f(ctx), "")
+}
+
+func TestObserveFloat(t *testing.T) {
+	ctx := context.Background()
+
+	values := []float64{0.5, 1.5, 0.25}
+
+	var observed []float64
+
+	f := feature.ObserveFloat(func(context.Context) float64 {
+		v := values[0]
+		values = values[1:]
+		return v
+	}, func(v float64) {
+		observed = append(observed, v)
+	})
+
+	assertEquals(t, 0.5, f(ctx), "")
+	assertEquals(t, 1.5, f(ctx), "")
+	assertEquals(t, 0.25, f(ctx), "")
+
+	assertEquals(t, []float64{0.5, 1.5, 0.25}, observed, "")
+}
+
+func TestOnChange(t *testing.T) {
+	ctx := context.Background()
+
+	values := []string{"a", "a", "b", "b", "a"}
+
+	type change struct{ Old, New string }
+
+	var changes []change
+
+	f := feature.OnChange(func(context.Context) string {
+		v := values[0]
+		values = values[1:]
+		return v
+	}, func(old, new string) {
+		changes = append(changes, change{old, new})
+	})
+
+	for range len(values) {
+		_ = f(ctx)
+	}
+
+	assertEquals(t, []change{{"a", "b"}, {"b", "a"}}, changes, "")
+}
+
+func TestRateLimited(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var calls int64
+
+	f := feature.RateLimited(func(context.Context) int64 {
+		calls++
+		return calls
+	}, time.Second, func() time.Time {
+		return now
+	})
+
+	assertEquals(t, 1, f(ctx), "first call")
+	assertEquals(t, 1, f(ctx), "call at same time")
+
+	now = now.Add(999 * time.Millisecond)
+	assertEquals(t, 1, f(ctx), "call before interval passed")
+
+	now = now.Add(time.Millisecond)
+	assertEquals(t, 2, f(ctx), "call after interval passed")
+	assertEquals(t, 2, f(ctx), "call directly after refresh")
+
+	now = now.Add(5 * time.Second)
+	assertEquals(t, 3, f(ctx), "call after multiple intervals passed")
+}
+
+func TestSafe(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("No panic", func(t *testing.T) {
+		f := feature.Safe(func(context.Context) string {
+			return "value"
+		}, "fallback", func(any) {
+			t.Error("onPanic called without panic")
+		})
+
+		assertEquals(t, "value", f(ctx), "")
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		var recovered any
+
+		f := feature.Safe(func(context.Context) string {
+			panic("boom")
+		}, "fallback", func(r any) {
+			recovered = r
+		})
+
+		assertEquals(t, "fallback", f(ctx), "")
+		assertEquals(t, any("boom"), recovered, "")
+	})
+
+	t.Run("Panic without hook", func(t *testing.T) {
+		f := feature.Safe(func(context.Context) int64 {
+			panic("boom")
+		}, 5, nil)
+
+		assertEquals(t, 5, f(ctx), "")
+	})
+}
@@ -2,4 +2,4 @@ module github.com/nussjustin/feature
 
 go 1.22
 
-require github.com/google/go-cmp v0.6.0 // indirect
+require github.com/google/go-cmp v0.6.0
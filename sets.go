@@ -0,0 +1,47 @@
+package feature
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// ErrDuplicateFlagSet is returned if a [FlagSet] with a given name is already registered.
+var ErrDuplicateFlagSet = errors.New("duplicate flag set")
+
+var (
+	setsMu sync.Mutex
+	sets   map[string]*FlagSet
+)
+
+// Register registers the given [FlagSet] under the given name, making it available via [Sets].
+//
+// If a [FlagSet] with the same name is already registered, the call will panic with an error that is
+// [ErrDuplicateFlagSet].
+func Register(name string, s *FlagSet) {
+	setsMu.Lock()
+	defer setsMu.Unlock()
+
+	if _, ok := sets[name]; ok {
+		panic(fmt.Errorf("%w: %s", ErrDuplicateFlagSet, name))
+	}
+
+	if sets == nil {
+		sets = make(map[string]*FlagSet)
+	}
+
+	sets[name] = s
+}
+
+// Sets returns all sets registered via [Register], keyed by name.
+func Sets() map[string]*FlagSet {
+	setsMu.Lock()
+	defer setsMu.Unlock()
+
+	m := maps.Clone(sets)
+	if m == nil {
+		m = make(map[string]*FlagSet)
+	}
+	return m
+}
@@ -0,0 +1,42 @@
+package feature_test
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nussjustin/feature"
+)
+
+// registerRuns is used to create unique names for each run of TestRegister, as registered sets can not be removed.
+var registerRuns atomic.Int64
+
+func TestRegister(t *testing.T) {
+	prefix := "TestRegister/" + strconv.FormatInt(registerRuns.Add(1), 10)
+	nameA, nameB := prefix+"/A", prefix+"/B"
+
+	var setA, setB feature.FlagSet
+
+	feature.Register(nameA, &setA)
+	feature.Register(nameB, &setB)
+
+	sets := feature.Sets()
+
+	if sets[nameA] != &setA {
+		t.Error("set A not registered")
+	}
+
+	if sets[nameB] != &setB {
+		t.Error("set B not registered")
+	}
+
+	delete(sets, nameA)
+
+	if _, ok := feature.Sets()[nameA]; !ok {
+		t.Error("modifying returned map removed registered set")
+	}
+
+	assertPanic(t, feature.ErrDuplicateFlagSet, func() {
+		feature.Register(nameA, &feature.FlagSet{})
+	})
+}
@@ -8,6 +8,9 @@ import (
 type sortedMap[T any] struct {
 	m    map[string]T
 	keys []string
+
+	// unordered disables sorting of keys, leaving them in insertion order.
+	unordered bool
 }
 
 func (s sortedMap[T]) add(key string, val T) sortedMap[T] {
@@ -15,7 +18,7 @@ func (s sortedMap[T]) add(key string, val T) sortedMap[T] {
 }
 
 func (s sortedMap[T]) addMany(m map[string]T) sortedMap[T] {
-	s2 := sortedMap[T]{m: maps.Clone(s.m), keys: slices.Clone(s.keys)}
+	s2 := sortedMap[T]{m: maps.Clone(s.m), keys: slices.Clone(s.keys), unordered: s.unordered}
 
 	if s2.m == nil {
 		s2.m = make(map[string]T, 1)
@@ -28,7 +31,7 @@ func (s sortedMap[T]) addMany(m map[string]T) sortedMap[T] {
 		s2.m[key] = val
 	}
 
-	if len(s.keys) != len(s2.keys) {
+	if !s.unordered && len(s.keys) != len(s2.keys) {
 		slices.Sort(s2.keys)
 	}
 
@@ -0,0 +1,152 @@
+package feature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedType is returned by [RegisterStruct] for fields with a type that can not be used as a flag.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// RegisterStruct registers a flag on s for each field of the struct v that has a "feature" tag.
+//
+// v must be a struct or a pointer to a struct.
+//
+// The tag has the form "name,description", where the description is optional. If the name is empty, the field name is
+// used instead.
+//
+// The kind of each flag is derived from the kind of the field type, so for example fields of type int, int32 or
+// [time.Duration] result in an int flag. Fields must have a boolean, float, integer, string or unsigned integer type.
+// For fields with any other type an error that is [ErrUnsupportedType] is returned.
+//
+// The returned map contains a function for each flag, keyed by the normalized flag name. The function has the type
+// func(context.Context) T, where T is the type of the field, and converts the value of the flag to T.
+//
+// If any field can not be registered, for example because a flag with the same name already exists or because s is
+// frozen, an error is returned and no flags are registered.
+func RegisterStruct(s *FlagSet, v any) (map[string]any, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: expected struct, got %T", ErrUnsupportedType, v)
+	}
+
+	var (
+		specs []Spec
+		types []reflect.Type
+	)
+
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		sf := rt.Field(i)
+
+		tag, ok := sf.Tag.Lookup("feature")
+		if !ok {
+			continue
+		}
+
+		name, desc, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+
+		kind := kindOf(sf.Type)
+		if kind == FlagKindInvalid {
+			return nil, fmt.Errorf("%w: field %s has type %s", ErrUnsupportedType, sf.Name, sf.Type)
+		}
+
+		specs = append(specs, Spec{Kind: kind, Name: s.normalize(name), Description: desc})
+		types = append(types, sf.Type)
+	}
+
+	s.loadLazy()
+
+	s.flagsMu.Lock()
+	err := s.checkSpecsLocked(specs)
+	s.flagsMu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := make(map[string]any, len(specs))
+
+	for i, spec := range specs {
+		var fn any
+
+		//nolint:exhaustive // Invalid kinds are rejected above
+		switch spec.Kind {
+		case FlagKindBool:
+			fn = s.Bool(spec.Name, WithDescription(spec.Description))
+		case FlagKindFloat:
+			fn = s.Float(spec.Name, WithDescription(spec.Description))
+		case FlagKindInt:
+			fn = s.Int(spec.Name, WithDescription(spec.Description))
+		case FlagKindString:
+			fn = s.String(spec.Name, WithDescription(spec.Description))
+		case FlagKindUint:
+			fn = s.Uint(spec.Name, WithDescription(spec.Description))
+		}
+
+		funcs[spec.Name] = convertFunc(fn, types[i])
+	}
+
+	return funcs, nil
+}
+
+func kindOf(t reflect.Type) FlagKind {
+	//nolint:exhaustive // All other kinds are unsupported
+	switch t.Kind() {
+	case reflect.Bool:
+		return FlagKindBool
+	case reflect.Float32, reflect.Float64:
+		return FlagKindFloat
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return FlagKindInt
+	case reflect.String:
+		return FlagKindString
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FlagKindUint
+	default:
+		return FlagKindInvalid
+	}
+}
+
+// convertFunc returns a function that calls fn, which is a flag function, and converts the result to t.
+//
+// Common types are converted using [Map]. Other types fall back to a slower, reflection based conversion.
+func convertFunc(fn any, t reflect.Type) any {
+	switch t {
+	case reflect.TypeFor[float32]():
+		return Map(fn.(func(context.Context) float64), func(v float64) float32 { return float32(v) })
+	case reflect.TypeFor[int]():
+		return Map(fn.(func(context.Context) int64), func(v int64) int { return int(v) })
+	case reflect.TypeFor[int32]():
+		return Map(fn.(func(context.Context) int64), func(v int64) int32 { return int32(v) })
+	case reflect.TypeFor[time.Duration]():
+		return Map(fn.(func(context.Context) int64), func(v int64) time.Duration { return time.Duration(v) })
+	case reflect.TypeFor[uint]():
+		return Map(fn.(func(context.Context) uint64), func(v uint64) uint { return uint(v) })
+	case reflect.TypeFor[uint32]():
+		return Map(fn.(func(context.Context) uint64), func(v uint64) uint32 { return uint32(v) })
+	}
+
+	fv := reflect.ValueOf(fn)
+	if fv.Type().Out(0) == t {
+		return fn
+	}
+
+	ft := reflect.FuncOf([]reflect.Type{reflect.TypeFor[context.Context]()}, []reflect.Type{t}, false)
+
+	return reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+		return []reflect.Value{fv.Call(args)[0].Convert(t)}
+	}).Interface()
+}
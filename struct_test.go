@@ -0,0 +1,175 @@
+package feature_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nussjustin/feature"
+)
+
+func TestRegisterStruct(t *testing.T) {
+	t.Run("Register", func(t *testing.T) {
+		ctx := context.Background()
+
+		type level int8
+
+		var config struct {
+			NewUI    bool          `feature:"new-ui,enables the new UI"`
+			Ratio    float64       `feature:"ratio"`
+			Limit    int64         `feature:",request limit"`
+			Theme    string        `feature:"theme"`
+			Retries  uint64        `feature:"retries"`
+			Workers  int           `feature:"workers"`
+			Timeout  time.Duration `feature:"timeout"`
+			Level    level         `feature:"level"`
+			Untagged time.Duration
+		}
+
+		var set feature.FlagSet
+		set.SetRegistry(testRegistry)
+
+		funcs, err := feature.RegisterStruct(&set, &config)
+		if err != nil {
+			t.Fatalf("failed to register struct: %s", err)
+		}
+
+		assertEquals(t, 8, len(funcs), "unexpected number of funcs")
+		assertEquals(t, true, funcs["new-ui"].(func(context.Context) bool)(ctx), "")
+		assertEquals(t, 2.5, funcs["ratio"].(func(context.Context) float64)(ctx), "")
+		assertEquals(t, 1, funcs["Limit"].(func(context.Context) int64)(ctx), "")
+		assertEquals(t, "string", funcs["theme"].(func(context.Context) string)(ctx), "")
+		assertEquals(t, 2, funcs["retries"].(func(context.Context) uint64)(ctx), "")
+		assertEquals(t, 1, funcs["workers"].(func(context.Context) int)(ctx), "")
+		assertEquals(t, time.Duration(1), funcs["timeout"].(func(context.Context) time.Duration)(ctx), "")
+		assertEquals(t, level(1), funcs["level"].(func(context.Context) level)(ctx), "")
+
+		assertEquals(t, "enables the new UI", mustLookup(t, &set, "new-ui").Description, "")
+		assertEquals(t, "request limit", mustLookup(t, &set, "Limit").Description, "")
+		assertEquals(t, feature.FlagKindUint, mustLookup(t, &set, "retries").Kind, "")
+		assertEquals(t, feature.FlagKindInt, mustLookup(t, &set, "timeout").Kind, "")
+	})
+
+	t.Run("Normalized names", func(t *testing.T) {
+		var config struct {
+			NewUI bool `feature:"New-UI"`
+		}
+
+		var set feature.FlagSet
+		set.SetNameNormalizer(strings.ToLower)
+
+		funcs, err := feature.RegisterStruct(&set, config)
+		if err != nil {
+			t.Fatalf("failed to register struct: %s", err)
+		}
+
+		if _, ok := funcs["new-ui"]; !ok {
+			t.Errorf("no function for normalized name, got %v", funcs)
+		}
+	})
+
+	t.Run("Frozen", func(t *testing.T) {
+		var config struct {
+			NewUI bool `feature:"new-ui"`
+		}
+
+		var set feature.FlagSet
+		set.Freeze()
+
+		_, err := feature.RegisterStruct(&set, config)
+		if !errors.Is(err, feature.ErrFrozen) {
+			t.Errorf("expected error %q, got %q", feature.ErrFrozen, err)
+		}
+	})
+
+	t.Run("Defined at", func(t *testing.T) {
+		var config struct {
+			NewUI bool `feature:"new-ui"`
+		}
+
+		var set feature.FlagSet
+		set.SetCaptureCallers(true)
+
+		_, file, line, _ := runtime.Caller(0)
+		if _, err := feature.RegisterStruct(&set, config); err != nil {
+			t.Fatalf("failed to register struct: %s", err)
+		}
+
+		assertEquals(t, file+":"+strconv.Itoa(line+1), mustLookup(t, &set, "new-ui").DefinedAt, "")
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		var config struct {
+			NewUI  bool     `feature:"new-ui"`
+			Themes []string `feature:"themes"`
+		}
+
+		var set feature.FlagSet
+
+		_, err := feature.RegisterStruct(&set, config)
+		if !errors.Is(err, feature.ErrUnsupportedType) {
+			t.Errorf("expected error %q, got %q", feature.ErrUnsupportedType, err)
+		}
+
+		assertEquals(t, 0, len(slicesCollect(set.All)), "flags registered despite error")
+	})
+
+	t.Run("Not a struct", func(t *testing.T) {
+		var set feature.FlagSet
+
+		_, err := feature.RegisterStruct(&set, 1)
+		if !errors.Is(err, feature.ErrUnsupportedType) {
+			t.Errorf("expected error %q, got %q", feature.ErrUnsupportedType, err)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		var config struct {
+			NewUI bool `feature:"new-ui"`
+		}
+
+		var set feature.FlagSet
+		set.String("new-ui")
+
+		_, err := feature.RegisterStruct(&set, config)
+		if !errors.Is(err, feature.ErrDuplicateFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrDuplicateFlag, err)
+		}
+	})
+
+	t.Run("Duplicate tag", func(t *testing.T) {
+		var config struct {
+			NewUI    bool `feature:"new-ui"`
+			NewUIOld bool `feature:"new-ui"`
+		}
+
+		var set feature.FlagSet
+
+		_, err := feature.RegisterStruct(&set, config)
+		if !errors.Is(err, feature.ErrDuplicateFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrDuplicateFlag, err)
+		}
+	})
+	t.Run("Duplicate normalized tag", func(t *testing.T) {
+		var config struct {
+			A      bool `feature:"a"`
+			UpperA bool `feature:"A"`
+		}
+
+		var set feature.FlagSet
+		set.SetNameNormalizer(strings.ToLower)
+
+		_, err := feature.RegisterStruct(&set, config)
+		if !errors.Is(err, feature.ErrDuplicateFlag) {
+			t.Errorf("expected error %q, got %q", feature.ErrDuplicateFlag, err)
+		}
+
+		if _, ok := set.Lookup("a"); ok {
+			t.Error("flag registered despite error")
+		}
+	})
+}